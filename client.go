@@ -1,14 +1,20 @@
 package xmlrpc
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
-
-	"github.com/literatesnow/xmlrpc/util"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const (
@@ -18,80 +24,443 @@ const (
 	squareRight = json.Delim(']')
 )
 
+//Sends a request document of length bytes, returning the response document.
+//The request is encoded as it's read, so it's never held in memory whole.
+type Transport interface {
+	RoundTrip(ctx context.Context, request io.Reader, length int64) (response io.ReadCloser, err error)
+}
+
+//Performs XML-RPC calls against a HTTP endpoint, or any other Transport
+type Client struct {
+	URL           string
+	HttpClient    *http.Client //http.DefaultClient if nil
+	Transport     Transport    //HTTP POST to URL if nil
+	EncodeOptions EncodeOptions
+	DecodeOptions DecodeOptions
+}
+
+func NewClient(url string) *Client {
+	return &Client{URL: url}
+}
+
+//Calls methodName on the server, returning the result or an error if the call
+//could not be made or the response could not be parsed
+func (c *Client) Call(ctx context.Context, methodName string, params ...Value) (value *Value, err error) {
+	encode := func(writer io.Writer) error {
+		encoder := NewEncoder(writer)
+		encoder.EncodeOptions = c.EncodeOptions
+		return encoder.EncodeRequest(methodName, params)
+	}
+
+	//encoding twice, first only to count, keeps large base64 params from
+	//being copied into a buffer
+	var counter countWriter
+	if err = encode(&counter); err != nil {
+		return nil, err
+	}
+
+	reader, writer := io.Pipe()
+	defer reader.Close()
+
+	go func() {
+		writer.CloseWithError(encode(writer))
+	}()
+
+	response, err := c.transport().RoundTrip(ctx, reader, counter.count)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Close()
+
+	decoder := NewDecoder(response)
+	decoder.DecodeOptions = c.DecodeOptions
+
+	return decoder.DecodeResponse()
+}
+
+func (c *Client) transport() Transport {
+	if c.Transport != nil {
+		return c.Transport
+	}
+	return &httpTransport{url: c.URL, client: c.HttpClient}
+}
+
+type httpTransport struct {
+	url    string
+	client *http.Client
+}
+
+func (t *httpTransport) RoundTrip(ctx context.Context, body io.Reader, length int64) (response io.ReadCloser, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	request.ContentLength = length
+
+	request.Header.Set("Content-Type", "text/xml")
+
+	client := t.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Unexpected HTTP status: %s", resp.Status)
+	}
+
+	if err = checkContentType(resp.Header.Get("Content-Type")); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+//Counts the bytes written to it
+type countWriter struct {
+	count int64
+}
+
+func (w *countWriter) Write(p []byte) (n int, err error) {
+	w.count += int64(len(p))
+	return len(p), nil
+}
+
+func checkContentType(contentType string) (err error) {
+	if contentType == "" {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	switch mediaType {
+	case "text/xml", "application/xml":
+		return nil
+	}
+
+	return errors.New("Unexpected content type: " + mediaType)
+}
+
 func CreateRequest(methodName string, params []Value) (document []byte) {
 	var buf bytes.Buffer
+	NewEncoder(&buf).EncodeRequest(methodName, params)
+	return buf.Bytes()
+}
+
+//Creates a <methodResponse> document returning value
+func CreateResponse(value Value) (document []byte) {
+	var buf bytes.Buffer
+	NewEncoder(&buf).EncodeResponse(value)
+	return buf.Bytes()
+}
+
+//Creates a <methodResponse> document containing a <fault>
+func CreateFault(code int, message string) (document []byte) {
+	var buf bytes.Buffer
+	NewEncoder(&buf).EncodeFault(code, message)
+	return buf.Bytes()
+}
 
-	writer := bufio.NewWriter(&buf)
-	writer.WriteString(xml.Header)
+//Options controlling how documents are decoded
+type DecodeOptions struct {
+	AllowExtraParams bool           //use the first of several response params instead of failing
+	Lenient          bool           //use zero or the nearest value in range for invalid scalars instead of failing
+	Location         *time.Location //zone for dateTime values without one, UTC if nil
+	Strict           bool           //fail on anything the specification or Apache extensions don't allow
+
+	//Limits for untrusted documents, exceeding one fails with *LimitExceeded.
+	//Zero is unlimited, except for MaxDepth. Text is checked once
+	//xml.Decoder has read the whole token, so only MaxBytes bounds memory.
+	MaxDepth        int   //nesting of arrays and structs, DefaultMaxDepth when zero and unlimited when negative
+	MaxBytes        int64 //size of the document
+	MaxStringLength int   //bytes of text in a value, member name or methodName
+	MaxElements     int   //values in a params or array, or members in a struct
+}
 
-	encoder := xml.NewEncoder(writer)
+//Nesting allowed when MaxDepth is zero, deeper than any real document but far
+//short of exhausting the stack
+const DefaultMaxDepth = 1000
+
+//Reads XML-RPC documents from a stream, decoding values as they arrive
+type Decoder struct {
+	DecodeOptions
+	decoder *xml.Decoder
+	path    []string
+	popPath bool
+	depth   int
+}
 
-	util.Start(encoder, "methodCall")
-	util.Start(encoder, "methodName")
-	util.CharData(encoder, methodName)
-	util.End(encoder, "methodName")
-	xmlParams(encoder, params)
-	util.End(encoder, "methodCall")
+func NewDecoder(reader io.Reader) *Decoder {
+	d := &Decoder{}
+	d.decoder = xml.NewDecoder(&limitReader{reader: reader, decoder: d})
 
-	encoder.Flush()
+	return d
+}
 
-	return buf.Bytes()
+//Fails reads past the decoder's MaxBytes
+type limitReader struct {
+	reader  io.Reader
+	decoder *Decoder
+	count   int64
+}
+
+func (r *limitReader) Read(p []byte) (n int, err error) {
+	max := r.decoder.MaxBytes
+	if max <= 0 {
+		return r.reader.Read(p)
+	}
+
+	if r.count >= max {
+		return 0, r.decoder.limitExceeded("MaxBytes", max)
+	}
+
+	//read one byte more than allowed to tell a document of exactly max bytes
+	//from a longer one
+	if remaining := max + 1 - r.count; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+
+	n, err = r.reader.Read(p)
+	r.count += int64(n)
+
+	if r.count > max {
+		return n - 1, r.decoder.limitExceeded("MaxBytes", max)
+	}
+
+	return n, err
 }
 
 func ParseResponse(response *bytes.Buffer) (value *Value, err error) {
-	decoder := xml.NewDecoder(response)
+	return NewDecoder(response).DecodeResponse()
+}
+
+//Parses a <methodResponse> document with any number of params
+func ParseResponseParams(response io.Reader) (values []Value, err error) {
+	return NewDecoder(response).DecodeResponseParams()
+}
+
+//Parses a <methodResponse> document, returning a *Fault error for a <fault>.
+//Fails if there's more than one param unless AllowExtraParams is set.
+func (d *Decoder) DecodeResponse() (value *Value, err error) {
+	values, err := d.DecodeResponseParams()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return nil, d.parseError("Expecting param element")
+	}
+
+	if len(values) > 1 && !d.AllowExtraParams {
+		return nil, d.parseError("Expecting one param, got " + strconv.Itoa(len(values)))
+	}
 
+	return &values[0], nil
+}
+
+//Parses a <methodResponse> document, returning every param or a *Fault error
+//for a <fault>
+func (d *Decoder) DecodeResponseParams() (values []Value, err error) {
 	var name *string
 
-	if name, err = nextElem(decoder); err == nil && *name != "methodResponse" {
-		err = errors.New("Expecting methodResponse element")
+	if name, err = d.nextElem(); err == nil && *name != "methodResponse" {
+		err = d.parseError("Expecting methodResponse element")
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	if name, err = nextElem(decoder); err == nil {
+	if name, err = d.nextElem(); err == nil {
 		switch *name {
 		case "fault":
-			value, err = parseFault(decoder)
+			err = d.parseFault()
 		case "params":
-			value, err = parseParams(decoder)
+			if values, err = d.parseParams(); err == nil {
+				err = d.checkEnd()
+			}
 		default:
-			return nil, errors.New("Unexpected element")
+			return nil, d.parseError("Unexpected element " + *name)
 		}
 	}
 
-	return value, err
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
 }
 
-func parseFault(decoder *xml.Decoder) (value *Value, err error) {
-	return parseValue(decoder)
+//Parses a <methodCall> document, as created by CreateRequest
+func ParseRequest(request io.Reader) (methodName string, params []Value, err error) {
+	return NewDecoder(request).DecodeRequest()
 }
 
-func parseParams(decoder *xml.Decoder) (value *Value, err error) {
-	if name, err := nextElem(decoder); err == nil && *name != "param" {
-		err = errors.New("Unexpected element")
+//Parses a <methodCall> document
+func (d *Decoder) DecodeRequest() (methodName string, params []Value, err error) {
+	var name *string
+
+	if name, err = d.nextElem(); err == nil && *name != "methodCall" {
+		err = d.parseError("Expecting methodCall element")
 	}
 
 	if err != nil {
-		return nil, err
+		return "", nil, err
+	}
+
+	if name, err = d.nextElem(); err == nil && *name != "methodName" {
+		err = d.parseError("Expecting methodName element")
 	}
 
-	value, err = parseValue(decoder)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
-	return value, nil
+	if methodName, err = d.parseMethodName(); err != nil {
+		return "", nil, err
+	}
+
+	if params, err = d.parseRequestParams(); err != nil {
+		return "", nil, err
+	}
+
+	if err = d.checkEnd(); err != nil {
+		return "", nil, err
+	}
+
+	return methodName, params, nil
+}
+
+func (d *Decoder) parseMethodName() (methodName string, err error) {
+	var text strings.Builder
+
+	for {
+		token, err := d.token()
+		if err != nil {
+			return "", err
+		}
+
+		switch elem := token.(type) {
+		case xml.CharData:
+			if err = d.checkString(text.Len() + len(elem)); err != nil {
+				return "", err
+			}
+			text.Write(elem)
+		case xml.StartElement:
+			return "", d.parseError("Unexpected element " + elem.Name.Local)
+		case xml.EndElement:
+			if text.Len() == 0 {
+				return "", d.parseError("Empty methodName")
+			}
+			if d.Strict && !strictMethodName(text.String()) {
+				return "", d.parseError("Invalid methodName " + strconv.Quote(text.String()))
+			}
+			return text.String(), nil
+		}
+	}
+}
+
+func (d *Decoder) parseRequestParams() (params []Value, err error) {
+	for {
+		token, err := d.token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch elem := token.(type) {
+		case xml.CharData:
+			if err = d.checkSpace(elem); err != nil {
+				return nil, err
+			}
+		case xml.StartElement:
+			if elem.Name.Local != "params" || params != nil {
+				return nil, d.parseError("Unexpected element " + elem.Name.Local)
+			}
+			if params, err = d.parseParams(); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			return params, nil
+		}
+	}
+}
+
+func (d *Decoder) parseFault() (err error) {
+	value, err := d.parseValue()
+	if err != nil {
+		return err
+	}
+
+	fault, err := newFault(value)
+	if err != nil {
+		return err
+	}
+
+	if err = d.checkEnd(); err != nil {
+		return err
+	}
+
+	return fault
 }
 
-func parseValue(decoder *xml.Decoder) (value *Value, err error) {
+func (d *Decoder) parseParams() (values []Value, err error) {
+	values = make([]Value, 0)
+
+	for {
+		token, err := d.token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch elem := token.(type) {
+		case xml.CharData:
+			if err = d.checkSpace(elem); err != nil {
+				return nil, err
+			}
+		case xml.StartElement:
+			if elem.Name.Local != "param" {
+				return nil, d.parseError("Expecting param element")
+			}
+
+			value, err := d.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if value == nil {
+				return nil, d.parseError("Expecting value element")
+			}
+
+			if err = d.checkElements(len(values)); err != nil {
+				return nil, err
+			}
+
+			values = append(values, *value)
+		case xml.EndElement:
+			if elem.Name.Local == "params" {
+				return values, nil
+			}
+		}
+	}
+}
+
+func (d *Decoder) parseValue() (value *Value, err error) {
 	value = nil
 	hasChar := false
 
+	var text strings.Builder
+
 	for {
-		token, err := decoder.Token()
+		token, err := d.token()
 		if err != nil {
 			return nil, err
 		}
@@ -103,14 +472,28 @@ func parseValue(decoder *xml.Decoder) (value *Value, err error) {
 		switch elem := token.(type) {
 		case xml.CharData:
 			if value != nil && hasChar {
-				value.FromString(string(elem))
+				if err = d.checkString(text.Len() + len(elem)); err != nil {
+					return nil, err
+				}
+				text.Write(elem)
+			} else if err = d.checkSpace(elem); err != nil {
+				return nil, err
 			}
 		case xml.StartElement:
+			if err = d.checkSpace(xml.CharData(text.String())); err != nil {
+				return nil, err
+			}
 			hasChar = true
-			if err = parseStartElement(decoder, &value, elem.Name.Local); err != nil {
+			text.Reset()
+			if err = d.parseStartElement(&value, elem.Name); err != nil {
 				return nil, err
 			}
 		case xml.EndElement:
+			if value != nil && hasChar {
+				if err = d.parseText(value, elem.Name.Local, text.String()); err != nil {
+					return nil, err
+				}
+			}
 			hasChar = false
 			if elem.Name.Local == "value" || value == nil {
 				return value, nil
@@ -121,39 +504,83 @@ func parseValue(decoder *xml.Decoder) (value *Value, err error) {
 	return value, nil
 }
 
-func parseStartElement(decoder *xml.Decoder, valuePtr **Value, elemName string) (err error) {
+func (d *Decoder) parseText(value *Value, elemName string, text string) (err error) {
+	if err = value.fromString(text, d.Location); err != nil && !d.Lenient {
+		return d.parseError("Invalid " + elemName + " " + strconv.Quote(text))
+	}
+
+	if d.Strict && !strictText(value, text) {
+		return d.parseError("Invalid " + elemName + " " + strconv.Quote(text))
+	}
+
+	return nil
+}
+
+func (d *Decoder) parseStartElement(valuePtr **Value, name xml.Name) (err error) {
+	elemName := name.Local
+
 	if elemName == "value" {
 		*valuePtr = &Value{}
 		return nil
 	}
 
 	if *valuePtr == nil {
+		if d.Strict {
+			return d.parseError("Unexpected element " + elemName)
+		}
 		return nil
 	}
 
 	value := *valuePtr
-	value.String = nil //Clear any white space if there's a type element
 
-	if err = value.FromRpc(elemName); err != nil {
-		return err
+	if d.Strict {
+		if value.Kind() != KindInvalid || !strictType(name) {
+			return d.parseError("Unexpected element " + elemName)
+		}
+	}
+
+	typeName := elemName
+
+	switch name.Space {
+	case "", "ex": //no namespace, or an undeclared ex prefix as written by older encoders
+	case ExtensionsNamespace:
+		typeName = "ex:" + elemName
+	default:
+		return d.parseError("Unhandled element " + elemName + " in namespace " + name.Space)
+	}
+
+	if err = value.FromRpc(typeName); err != nil {
+		return d.parseError(err.Error())
+	}
+
+	if value.Array == nil && value.Struct == nil {
+		return nil
+	}
+
+	d.depth++
+	defer func() { d.depth-- }()
+
+	max := d.MaxDepth
+	if max == 0 {
+		max = DefaultMaxDepth
+	}
+
+	if max > 0 && d.depth > max {
+		return d.limitExceeded("MaxDepth", int64(max))
 	}
 
 	if value.Array != nil {
-		if err = parseValueArray(decoder, value); err != nil {
-			return err
-		}
-	} else if value.Struct != nil {
-		if err = parseValueStruct(decoder, value); err != nil {
-			return err
-		}
+		return d.parseValueArray(value)
 	}
 
-	return nil
+	return d.parseValueStruct(value)
 }
 
-func parseValueArray(decoder *xml.Decoder, value *Value) (err error) {
-	if name, err := nextElem(decoder); err == nil && *name != "data" {
-		err = errors.New("Unexpected element")
+func (d *Decoder) parseValueArray(value *Value) (err error) {
+	var name *string
+
+	if name, err = d.nextElem(); err == nil && *name != "data" {
+		err = d.parseError("Expecting data element")
 	}
 
 	if err != nil {
@@ -161,11 +588,14 @@ func parseValueArray(decoder *xml.Decoder, value *Value) (err error) {
 	}
 
 	for {
-		val, err := parseValue(decoder)
+		val, err := d.parseValue()
 		if err != nil {
 			return err
 		}
 		if val != nil {
+			if err = d.checkElements(len(value.Array)); err != nil {
+				return err
+			}
 			value.Array = append(value.Array, *val)
 		} else {
 			break
@@ -175,12 +605,12 @@ func parseValueArray(decoder *xml.Decoder, value *Value) (err error) {
 	return nil
 }
 
-func parseValueStruct(decoder *xml.Decoder, value *Value) (err error) {
+func (d *Decoder) parseValueStruct(value *Value) (err error) {
 	var member *Member
 	var isName bool = false
 
 	for {
-		token, err := decoder.Token()
+		token, err := d.token()
 		if err != nil {
 			return err
 		}
@@ -194,6 +624,11 @@ func parseValueStruct(decoder *xml.Decoder, value *Value) (err error) {
 			if member != nil && isName {
 				member.Name = string(elem)
 				isName = false
+				if err = d.checkString(len(member.Name)); err != nil {
+					return err
+				}
+			} else if err = d.checkSpace(elem); err != nil {
+				return err
 			}
 		case xml.StartElement:
 			switch elem.Name.Local {
@@ -201,7 +636,7 @@ func parseValueStruct(decoder *xml.Decoder, value *Value) (err error) {
 				member = &Member{}
 			case "name":
 				if member == nil {
-					return errors.New("Bad member")
+					return d.parseError("Bad member")
 				}
 				isName = true
 			}
@@ -209,22 +644,28 @@ func parseValueStruct(decoder *xml.Decoder, value *Value) (err error) {
 			switch elem.Name.Local {
 			case "name":
 				if member != nil {
-					val, err := parseValue(decoder)
+					val, err := d.parseValue()
 					if err != nil {
 						return err
 					}
+					if val == nil && d.Strict {
+						return d.parseError("Expecting value element")
+					}
 					if val != nil {
 						member.Value = *val
 					}
 				}
 			case "member":
+				if err = d.checkElements(len(value.Struct)); err != nil {
+					return err
+				}
 				value.Struct = append(value.Struct, *member)
 				member = nil
 				isName = false
 			case "struct":
 				return nil
 			default:
-				return errors.New("Unhandled struct element " + elem.Name.Local)
+				return d.parseError("Unhandled struct element " + elem.Name.Local)
 			}
 		}
 	}
@@ -232,45 +673,176 @@ func parseValueStruct(decoder *xml.Decoder, value *Value) (err error) {
 	return nil
 }
 
-func nextElem(decoder *xml.Decoder) (name *string, err error) {
-	for {
-		token, err := decoder.Token()
-		if err != nil {
-			return nil, err
+//Next token, keeping track of the path to the current element
+func (d *Decoder) token() (token xml.Token, err error) {
+	if d.popPath {
+		d.path = d.path[:len(d.path)-1]
+		d.popPath = false
+	}
+
+	if token, err = d.decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	switch elem := token.(type) {
+	case xml.StartElement:
+		d.path = append(d.path, elem.Name.Local)
+		if d.Strict && elem.Name.Space != "" && elem.Name.Space != ExtensionsNamespace {
+			return nil, d.parseError("Unexpected namespace " + elem.Name.Space)
 		}
-		if token == nil {
-			return nil, errors.New("Expecting element start")
+	case xml.EndElement:
+		d.popPath = true
+	}
+
+	return token, nil
+}
+
+//Fails on text other than white space when Strict
+func (d *Decoder) checkSpace(text xml.CharData) (err error) {
+	if d.Strict && len(bytes.TrimSpace(text)) > 0 {
+		return d.parseError("Unexpected text " + strconv.Quote(string(text)))
+	}
+	return nil
+}
+
+//Whether name is a type from the specification, or an Apache extension type
+//in its namespace
+func strictType(name xml.Name) bool {
+	switch name.Space {
+	case "":
+		switch name.Local {
+		case "int", "i4", "boolean", "string", "double", "dateTime.iso8601", "base64", "array", "struct":
+			return true
+		}
+	case ExtensionsNamespace:
+		switch name.Local {
+		case "nil", "i1", "float", "i8", "i2":
+			return true
 		}
+	}
 
-		switch elem := token.(type) {
-		case xml.StartElement:
-			return &elem.Name.Local, nil
+	return false
+}
+
+//Whether text is in the format the specification gives for the value's type
+func strictText(value *Value, text string) bool {
+	text = strings.TrimSpace(text)
+
+	switch value.Kind() {
+	case KindBoolean:
+		return text == "0" || text == "1"
+	case KindDouble, KindFloat:
+		text = strings.TrimLeft(text, "+-")
+		whole, fraction, _ := strings.Cut(text, ".")
+		return whole+fraction != "" && isDigits(whole) && isDigits(fraction)
+	case KindDateTime:
+		_, err := time.Parse(DateTimeBasic, text)
+		return err == nil
+	case KindBase64:
+		_, err := base64.StdEncoding.DecodeString(*value.Base64)
+		return err == nil
+	case KindNil:
+		return text == ""
+	}
+
+	return true
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
 		}
 	}
+	return true
+}
 
-	return nil, errors.New("Expecting element")
+//Whether methodName only has the characters the specification allows
+func strictMethodName(methodName string) bool {
+	for _, r := range methodName {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '_', r == '.', r == ':', r == '/':
+		default:
+			return false
+		}
+	}
+	return true
 }
 
-func xmlParams(encoder *xml.Encoder, values []Value) {
-	if len(values) == 0 {
-		return
+//Fails on anything but white space and closing elements up to the end of the
+//document when Strict
+func (d *Decoder) checkEnd() (err error) {
+	if !d.Strict {
+		return nil
 	}
 
-	util.Start(encoder, "params")
+	for {
+		token, err := d.token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 
-	for _, val := range values {
-		util.Start(encoder, "param")
-		val.asXml(encoder)
-		util.End(encoder, "param")
+		switch elem := token.(type) {
+		case xml.CharData:
+			if err = d.checkSpace(elem); err != nil {
+				return err
+			}
+		case xml.StartElement:
+			return d.parseError("Unexpected element " + elem.Name.Local)
+		}
 	}
+}
 
-	util.End(encoder, "params")
+func (d *Decoder) nextElem() (name *string, err error) {
+	for {
+		token, err := d.token()
+		if err == io.EOF {
+			return nil, d.parseError("Expecting element")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch elem := token.(type) {
+		case xml.CharData:
+			if err = d.checkSpace(elem); err != nil {
+				return nil, err
+			}
+		case xml.StartElement:
+			return &elem.Name.Local, nil
+		}
+	}
 }
 
 //JSON
 
+//Rules for inferring types in untyped JSON. Integers are i4 when they fit and
+//i8 otherwise, other numbers are double, objects are structs, arrays are
+//arrays and null is nil.
+type JsonRules struct {
+	NoExtensions bool //integers outside i4 become double and null an empty string, rather than i8 and nil
+	DateTimes    bool //strings in a recognised dateTime.iso8601 format become dateTime rather than string
+}
+
+//Parses a request using the type keys of Value, such as
+//{"methodName":"d.name","params":[{"string":"main"}]}
 func ParseJsonRequest(body io.Reader) (methodName string, params []Value, err error) {
+	return parseJsonRequest(body, parseJsonValues)
+}
+
+//Parses a request with plain JSON params, such as
+//{"methodName":"d.name","params":["main"]}, inferring types using rules
+func ParseUntypedJsonRequest(body io.Reader, rules JsonRules) (methodName string, params []Value, err error) {
+	return parseJsonRequest(body, rules.parseValues)
+}
+
+func parseJsonRequest(body io.Reader, parseParams func(*json.Decoder) ([]Value, error)) (methodName string, params []Value, err error) {
 	decoder := json.NewDecoder(body)
+	decoder.UseNumber()
 
 	if err := nextJsonDelim(decoder, curlyLeft); err != nil {
 		return "", nil, err
@@ -292,7 +864,7 @@ func ParseJsonRequest(body io.Reader) (methodName string, params []Value, err er
 				return "", nil, err
 			}
 		case "params":
-			if params, err = parseJsonValues(decoder); err != nil {
+			if params, err = parseParams(decoder); err != nil {
 				return "", nil, err
 			}
 		}
@@ -328,73 +900,283 @@ func nextJsonDelim(decoder *json.Decoder, delim json.Delim) (err error) {
 }
 
 func parseJsonValues(decoder *json.Decoder) (values []Value, err error) {
-	if err := nextJsonDelim(decoder, squareLeft); err != nil {
+	if err = nextJsonDelim(decoder, squareLeft); err != nil {
 		return nil, err
 	}
 
 	values = make([]Value, 0)
-	hasType := false
 
-	var val *Value
+	for decoder.More() {
+		value, err := parseJsonValue(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if err = nextJsonDelim(decoder, squareRight); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+//Parses a typed value such as {"int":1}, {"array":[...]} or {"struct":...}
+func parseJsonValue(decoder *json.Decoder) (value Value, err error) {
+	if err = nextJsonDelim(decoder, curlyLeft); err != nil {
+		return Value{}, err
+	}
+
+	hasType := false
 
 	for {
 		token, err := decoder.Token()
-		if err == io.EOF {
-			break
+		if err != nil {
+			return Value{}, err
 		}
 
-		if err != nil {
-			return nil, err
+		if token == curlyRight {
+			return value, nil
 		}
 
-		if token == squareRight {
-			break
+		if !hasType {
+			str, ok := token.(string)
+			if !ok {
+				return Value{}, errors.New("Invalid token")
+			}
+
+			if err = value.FromRpc(str); err != nil {
+				return Value{}, err
+			}
+
+			hasType = true
+
+			if value.Array != nil {
+				value.Array, err = parseJsonValues(decoder)
+			} else if value.Struct != nil {
+				value.Struct, err = parseJsonMembers(decoder)
+			}
+
+			if err != nil {
+				return Value{}, err
+			}
+
+		} else {
+			switch p := token.(type) {
+			case string:
+				value.FromString(p)
+			case json.Number:
+				value.fromJsonNumber(p)
+			case bool:
+				value.FromBoolean(p)
+			case nil:
+			default:
+				return Value{}, errors.New("Unexpected token")
+			}
 		}
+	}
+}
+
+//Keeps i8 values exact, which they would not be as a float64
+func (v *Value) fromJsonNumber(num json.Number) {
+	if v.Long != nil {
+		if i, err := num.Int64(); err == nil {
+			*v.Long = i
+			return
+		}
+	}
+
+	f, _ := num.Float64()
+	v.FromNumber(f)
+}
 
-		if token == curlyLeft {
-			val = &Value{}
+//Parses struct members, either {"name":{"int":1},...} or
+//[{"name":"name","value":{"int":1}},...]
+func parseJsonMembers(decoder *json.Decoder) (members []Member, err error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
 
-		} else if token == curlyRight {
-			if val != nil {
-				values = append(values, *val)
-				val = nil
-				hasType = false
+	members = make([]Member, 0)
+
+	switch token {
+	case curlyLeft:
+		for decoder.More() {
+			var member Member
+
+			if member.Name, err = nextJsonString(decoder); err != nil {
+				return nil, err
+			}
+			if member.Value, err = parseJsonValue(decoder); err != nil {
+				return nil, err
 			}
 
-		} else if val != nil {
-			if !hasType {
-				if str, ok := token.(string); ok {
-					if err = val.FromRpc(str); err != nil {
-						return nil, err
-					}
+			members = append(members, member)
+		}
 
-					hasType = true
+		err = nextJsonDelim(decoder, curlyRight)
 
-					if val.Array != nil {
-						val.Array, err = parseJsonValues(decoder)
-						if err != nil {
-							return nil, err
-						}
-					}
+	case squareLeft:
+		for decoder.More() {
+			member, err := parseJsonMember(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			members = append(members, member)
+		}
+
+		err = nextJsonDelim(decoder, squareRight)
+
+	default:
+		return nil, errors.New("Unexpected token")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func parseJsonMember(decoder *json.Decoder) (member Member, err error) {
+	if err = nextJsonDelim(decoder, curlyLeft); err != nil {
+		return Member{}, err
+	}
+
+	for decoder.More() {
+		key, err := nextJsonString(decoder)
+		if err != nil {
+			return Member{}, err
+		}
+
+		switch key {
+		case "name":
+			member.Name, err = nextJsonString(decoder)
+		case "value":
+			member.Value, err = parseJsonValue(decoder)
+		default:
+			err = errors.New("Unexpected member key: " + key)
+		}
+
+		if err != nil {
+			return Member{}, err
+		}
+	}
+
+	if err = nextJsonDelim(decoder, curlyRight); err != nil {
+		return Member{}, err
+	}
+
+	return member, nil
+}
+
+func (r JsonRules) parseValues(decoder *json.Decoder) (values []Value, err error) {
+	if err = nextJsonDelim(decoder, squareLeft); err != nil {
+		return nil, err
+	}
+
+	return r.parseArray(decoder)
+}
+
+//Parses the rest of an array after its opening [
+func (r JsonRules) parseArray(decoder *json.Decoder) (values []Value, err error) {
+	values = make([]Value, 0)
 
-				} else {
-					return nil, errors.New("Invalid token")
+	for decoder.More() {
+		value, err := r.parseValue(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	if err = nextJsonDelim(decoder, squareRight); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (r JsonRules) parseValue(decoder *json.Decoder) (value Value, err error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return Value{}, err
+	}
+
+	switch p := token.(type) {
+	case json.Delim:
+		if p == squareLeft {
+			values, err := r.parseArray(decoder)
+			if err != nil {
+				return Value{}, err
+			}
+			return NewArray(values), nil
+		}
+
+		if p == curlyLeft {
+			members := make([]Member, 0)
+
+			for decoder.More() {
+				var member Member
+
+				if member.Name, err = nextJsonString(decoder); err != nil {
+					return Value{}, err
 				}
-			} else {
-				switch p := token.(type) {
-				case string:
-					val.FromString(p)
-				case float64:
-					val.FromNumber(p)
-				case bool:
-					val.FromBoolean(p)
-				case nil:
-				default:
-					return nil, errors.New("Unexpected token")
+				if member.Value, err = r.parseValue(decoder); err != nil {
+					return Value{}, err
 				}
+
+				members = append(members, member)
+			}
+
+			if err = nextJsonDelim(decoder, curlyRight); err != nil {
+				return Value{}, err
+			}
+			return NewStruct(members), nil
+		}
+
+	case json.Number:
+		return r.number(p)
+
+	case string:
+		if r.DateTimes {
+			if t, err := parseDateTime(p, time.UTC); err == nil {
+				return NewDateTime(t), nil
 			}
 		}
+		return NewString(p), nil
+
+	case bool:
+		return NewBoolean(p), nil
+
+	case nil:
+		if r.NoExtensions {
+			return NewString(""), nil
+		}
+		return NewNil(), nil
 	}
 
-	return values, nil
+	return Value{}, errors.New("Unexpected token")
+}
+
+func (r JsonRules) number(num json.Number) (value Value, err error) {
+	if i, err := num.Int64(); err == nil {
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			return NewInt(int32(i)), nil
+		}
+		if !r.NoExtensions {
+			return NewLong(i), nil
+		}
+	}
+
+	f, err := num.Float64()
+	if err != nil {
+		return Value{}, errors.New("Number out of range: " + num.String())
+	}
+
+	return NewDouble(f), nil
 }
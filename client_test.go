@@ -2,10 +2,17 @@ package xmlrpc
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -14,6 +21,9 @@ var ZZ time.Time
 
 // Both
 
+//Root of a request using extension types
+const exMethodCall = `<methodCall xmlns:ex="` + ExtensionsNamespace + `">`
+
 func createCompareRequest(methodName string, params []Value, expected string, t *testing.T) {
 	actual := string(CreateRequest(methodName, params))
 
@@ -43,8 +53,10 @@ func runParseXmlResponse(items []string, expecteds []Value, isParam bool, t *tes
 
 		t.Logf("Expected (XML): %s", xml)
 
-		buf := bytes.NewBufferString(xml)
-		actual, err := ParseResponse(buf)
+		//Fixtures include malformed values, which are expected as lenient parsing has them
+		decoder := NewDecoder(bytes.NewBufferString(xml))
+		decoder.Lenient = true
+		actual, err := decoder.DecodeResponse()
 
 		if err != nil {
 			t.Fatalf("Unexpected error: %s", err)
@@ -493,12 +505,16 @@ func dateTimeData() (xmlDoc []string, values []Value) {
 			"<dateTime.iso8601>2016-04-07T21:13:58+1200</dateTime.iso8601>",
 			"<dateTime8601>2016-04-07T21:13:58+1200</dateTime8601>",
 			"<dateTime>2016-04-07T21:13:58+1200</dateTime>",
-			"<dateTime.iso8601></dateTime.iso8601>"},
+			"<dateTime.iso8601></dateTime.iso8601>",
+			"<dateTime.iso8601>20160407T09:13:58</dateTime.iso8601>",
+			"<dateTime.iso8601>2016-04-07T21:13:58.000+12:00</dateTime.iso8601>"},
 		[]Value{
 			NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
 			NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
 			NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
-			NewDateTime(ZZ)}
+			NewDateTime(ZZ),
+			NewDateTime(time.Date(2016, 4, 7, 9, 13, 58, 0, time.UTC)),
+			NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ))}
 }
 
 func dateTimeValidData() (xmlDoc []string, values []Value) {
@@ -511,17 +527,21 @@ func dateTimeValidData() (xmlDoc []string, values []Value) {
 func base64Data() (xmlDoc []string, values []Value) {
 	return []string{
 			"<base64>TODO</base64>",
-			"<base64></base64>"},
+			"<base64></base64>",
+			"<base64>\n  aGVsbG8g\r\n  d29y bGQ=\n</base64>"},
 		[]Value{
 			NewBase64("TODO"),
-			NewBase64("")}
+			NewBase64(""),
+			NewBase64("aGVsbG8gd29ybGQ=")}
 }
 
 func base64ValidData() (xmlDoc []string, values []Value) {
 	return []string{
-			"<base64>TODO</base64>"},
+			"<base64>TODO</base64>",
+			"<base64>AAEC/w==</base64>"},
 		[]Value{
-			NewBase64("TODO")}
+			NewBase64("TODO"),
+			NewBase64Bytes([]byte{0, 1, 2, 255})}
 }
 
 func arrayData() (xmlDoc []string, values []Value) {
@@ -552,6 +572,39 @@ func arrayValidData() (xmlDoc []string, values []Value) {
 			NewLong(1294959993)}}}
 }
 
+func structValidData() (xmlDoc []string, values []Value) {
+	return []string{
+			"<struct>" +
+				"<member><name>name</name><value><string>main</string></value></member>" +
+				"<member><name>size</name><value><ex:i8>3000000000</ex:i8></value></member>" +
+				"<member><name>files</name><value><array><data>" +
+				"<value><struct><member><name>path</name><value><string>a &amp; b.txt</string></value></member></struct></value>" +
+				"</data></array></value></member>" +
+				"<member><name></name><value><struct></struct></value></member>" +
+				"</struct>"},
+		[]Value{NewStruct([]Member{
+			{Name: "name", Value: NewString("main")},
+			{Name: "size", Value: NewLong(3000000000)},
+			{Name: "files", Value: NewArray([]Value{
+				NewStruct([]Member{{Name: "path", Value: NewString("a & b.txt")}})})},
+			{Name: "", Value: NewStruct([]Member{})}})}
+}
+
+//Struct of arrays of structs, depth levels deep
+func nestedStructData(depth int) (value Value) {
+	if depth == 0 {
+		return NewString("leaf")
+	}
+
+	inner := nestedStructData(depth - 1)
+
+	return NewStruct([]Member{
+		{Name: "depth", Value: NewInt(int32(depth))},
+		{Name: "<items & more>", Value: NewArray([]Value{inner, NewArray([]Value{inner}), NewArray([]Value{})})},
+		{Name: "child", Value: inner},
+		{Name: "empty", Value: NewStruct([]Member{})}})
+}
+
 func structData() (xmlDoc []string, values []Value) {
 	return []string{
 			`<value>  <struct>
@@ -764,7 +817,7 @@ func mixedArrayData() (xmlDoc []string, values []Value) {
 				{Name: "A mighty fine struct", Value: NewString("It sure is")}})}}}
 }
 
-func faultData() (xmlDoc []string, values []Value) {
+func faultData() (xmlDoc []string, faults []Fault) {
 	return []string{
 			`<fault>
       <value><struct>
@@ -773,10 +826,19 @@ func faultData() (xmlDoc []string, values []Value) {
       <member><name>faultString</name>
       <value><string>Method 'what' not defined</string></value></member>
       </struct></value>
-    </fault>`},
-		[]Value{NewStruct([]Member{
-			{Name: "faultCode", Value: NewInt(-506)},
-			{Name: "faultString", Value: NewString("Method 'what' not defined")}})}
+    </fault>`,
+			`<fault><value><struct>
+      <member><name>faultString</name><value>No such file</value></member>
+      <member><name>faultCode</name><value><int>2</int></value></member>
+      </struct></value></fault>`,
+			`<fault><value><struct>
+      <member><name>faultCode</name><value><i8>-32601</i8></value></member>
+      <member><name>faultString</name><value><string>Method not found</string></value></member>
+      </struct></value></fault>`},
+		[]Fault{
+			{Code: -506, String: "Method 'what' not defined"},
+			{Code: 2, String: "No such file"},
+			{Code: -32601, String: "Method not found"}}
 }
 
 func TestIntegerParam(t *testing.T) {
@@ -850,8 +912,390 @@ func TestMixedArrayParam(t *testing.T) {
 }
 
 func TestFault(t *testing.T) {
-	xmlDoc, values := faultData()
-	runParseXmlResponse(xmlDoc, values, false, t)
+	xmlDoc, faults := faultData()
+
+	for i, item := range xmlDoc {
+		buf := bytes.NewBufferString(xml.Header + "<methodResponse>" + item + "</methodResponse>")
+		value, err := ParseResponse(buf)
+
+		if value != nil {
+			t.Fatalf("Expected no value, got %s", printValue(value))
+		}
+
+		var fault *Fault
+		if !errors.As(err, &fault) {
+			t.Fatalf("Expected fault, got %v", err)
+		}
+
+		if *fault != faults[i] {
+			t.Fatalf("Expected %#v, got %#v", faults[i], *fault)
+		}
+	}
+}
+
+func TestFaultNotStruct(t *testing.T) {
+	buf := bytes.NewBufferString(xml.Header + "<methodResponse><fault><value><int>1</int></value></fault></methodResponse>")
+
+	var fault *Fault
+	if _, err := ParseResponse(buf); err == nil || errors.As(err, &fault) {
+		t.Fatalf("Expected parse error, got %v", err)
+	}
+}
+
+func TestBase64Bytes(t *testing.T) {
+	items := []string{"aGVsbG8gd29ybGQ=", "aGVsbG8gd29ybGQ", "aGVs\nbG8g\r\nd29y bGQ=\n", ""}
+	expecteds := []string{"hello world", "hello world", "hello world", ""}
+
+	for i, item := range items {
+		value := NewBase64(item)
+
+		actual, err := value.Bytes()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if string(actual) != expecteds[i] {
+			t.Fatalf("Expected %q, got %q", expecteds[i], actual)
+		}
+	}
+
+	value := NewBase64("not base64!")
+	if _, err := value.Bytes(); err == nil {
+		t.Fatalf("Expected error for invalid base64")
+	}
+
+	value = NewString("aGVsbG8=")
+	if _, err := value.Bytes(); err == nil {
+		t.Fatalf("Expected error for string value")
+	}
+
+	data := []byte{0, 1, 2, 253, 254, 255}
+	value = NewBase64Bytes(data)
+
+	actual, err := value.Bytes()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !bytes.Equal(actual, data) {
+		t.Fatalf("Expected %v, got %v", data, actual)
+	}
+}
+
+func TestValidParamNotLenient(t *testing.T) {
+	fixtures := []func() ([]string, []Value){
+		integerValidData, booleanValidData, stringValidData, doubleValidData, dateTimeValidData,
+		base64ValidData, arrayValidData, nilValidData, byteValidData, floatValidData,
+		longValidData, shortValidData}
+
+	for _, fixture := range fixtures {
+		xmlDoc, values := fixture()
+
+		for i, item := range xmlDoc {
+			buf := bytes.NewBufferString(xml.Header + "<methodResponse><params><param><value>" +
+				item + "</value></param></params></methodResponse>")
+
+			actual, err := ParseResponse(buf)
+			if err != nil {
+				t.Fatalf("Unexpected error for %s: %s", item, err)
+			}
+
+			compareValue(&values[i], actual, t)
+		}
+	}
+}
+
+func TestLenientScalar(t *testing.T) {
+	items := []string{
+		"<int>abc</int>",
+		"<i4>2147483648</i4>",
+		"<boolean>yes</boolean>",
+		"<i2>40000</i2>",
+		"<i8></i8>"}
+	expecteds := []Value{
+		NewInt(0),
+		NewInt(math.MaxInt32),
+		NewBoolean(false),
+		NewShort(math.MaxInt16),
+		NewLong(0)}
+
+	for i, item := range items {
+		decoder := NewDecoder(strings.NewReader(xml.Header + "<methodResponse><params><param><value>" +
+			item + "</value></param></params></methodResponse>"))
+		decoder.Lenient = true
+
+		actual, err := decoder.DecodeResponse()
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", item, err)
+		}
+
+		compareValue(&expecteds[i], actual, t)
+	}
+}
+
+func TestInvalidScalar(t *testing.T) {
+	prefix := "methodResponse/params/param/value/"
+	items := []string{
+		"<int>abc</int>",
+		"<i4>2147483648</i4>",
+		"<boolean>yes</boolean>",
+		"<double>1.2.3</double>",
+		"<dateTime.iso8601>yesterday</dateTime.iso8601>",
+		"<i1>256</i1>",
+		"<i1>-1</i1>",
+		"<i2>40000</i2>",
+		"<i8></i8>",
+		"<float>1e39</float>",
+		"<struct><member><name>a</name><value><int>x</int></value></member></struct>",
+		"<array><data><value><i4>1</i4></value><value><boolean>2</boolean></value></data></array>"}
+	expecteds := []struct {
+		path string
+		text string
+	}{
+		{"int", `"abc"`},
+		{"i4", `"2147483648"`},
+		{"boolean", `"yes"`},
+		{"double", `"1.2.3"`},
+		{"dateTime.iso8601", `"yesterday"`},
+		{"i1", `"256"`},
+		{"i1", `"-1"`},
+		{"i2", `"40000"`},
+		{"i8", `""`},
+		{"float", `"1e39"`},
+		{"struct/member/value/int", `"x"`},
+		{"array/data/value/boolean", `"2"`}}
+
+	for i, item := range items {
+		buf := bytes.NewBufferString(xml.Header + "<methodResponse><params><param><value>" +
+			item + "</value></param></params></methodResponse>")
+
+		_, err := ParseResponse(buf)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected parse error for %s, got %v", item, err)
+		}
+
+		if parseErr.Path != prefix+expecteds[i].path {
+			t.Fatalf("Expected path %s, got %s", prefix+expecteds[i].path, parseErr.Path)
+		}
+
+		if !strings.Contains(parseErr.Msg, expecteds[i].text) {
+			t.Fatalf("Expected message to contain %s, got %s", expecteds[i].text, parseErr.Msg)
+		}
+	}
+}
+
+func decodeLimited(document string, options DecodeOptions) (err error) {
+	decoder := NewDecoder(strings.NewReader(document))
+	decoder.DecodeOptions = options
+
+	_, err = decoder.DecodeResponseParams()
+	return err
+}
+
+func TestDecodeLimits(t *testing.T) {
+	nested := NewArray([]Value{NewStruct([]Member{{Name: "a", Value: NewArray([]Value{NewInt(1)})}})})
+	document := string(CreateResponse(nested))
+
+	items := []struct {
+		document string
+		options  DecodeOptions
+		limit    string
+		path     string
+	}{
+		{document, DecodeOptions{MaxDepth: 2}, "MaxDepth", "methodResponse/params/param/value/array/data/value/struct/member/value/array"},
+		{document, DecodeOptions{MaxBytes: 40}, "MaxBytes", ""},
+		{string(CreateResponse(NewString(strings.Repeat("main", 5000)))), DecodeOptions{MaxBytes: 10000}, "MaxBytes", ""},
+		{string(CreateResponse(NewString("main"))), DecodeOptions{MaxStringLength: 3}, "MaxStringLength", "methodResponse/params/param/value/string"},
+		{string(CreateResponse(NewStruct([]Member{{Name: "name", Value: NewNil()}}))), DecodeOptions{MaxStringLength: 3}, "MaxStringLength", "methodResponse/params/param/value/struct/member/name"},
+		{string(CreateResponse(NewArray([]Value{NewInt(1), NewInt(2)}))), DecodeOptions{MaxElements: 1}, "MaxElements", "methodResponse/params/param/value/array/data/value"},
+		{string(CreateResponse(NewStruct([]Member{{Name: "a", Value: NewNil()}, {Name: "b", Value: NewNil()}}))), DecodeOptions{MaxElements: 1}, "MaxElements", "methodResponse/params/param/value/struct/member"},
+		{xml.Header + "<methodResponse><params><param><value/></param><param><value/></param></params></methodResponse>", DecodeOptions{MaxElements: 1}, "MaxElements", "methodResponse/params/param/value"},
+	}
+
+	for _, item := range items {
+		err := decodeLimited(item.document, item.options)
+
+		var limitErr *LimitExceeded
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("Expected %s exceeded, got %v", item.limit, err)
+		}
+
+		if limitErr.Limit != item.limit {
+			t.Fatalf("Expected %s exceeded, got %s", item.limit, limitErr.Limit)
+		}
+
+		if item.path != "" && limitErr.Path != item.path {
+			t.Fatalf("Expected path %s, got %s", item.path, limitErr.Path)
+		}
+	}
+}
+
+func TestDecodeWithinLimits(t *testing.T) {
+	value := NewArray([]Value{NewStruct([]Member{{Name: "abc", Value: NewArray([]Value{NewString("main")})}})})
+	document := string(CreateResponse(value))
+
+	options := DecodeOptions{MaxDepth: 3, MaxBytes: int64(len(document)), MaxStringLength: 4, MaxElements: 1}
+	if err := decodeLimited(document, options); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := decodeLimited(document, DecodeOptions{}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func TestMethodNameLimit(t *testing.T) {
+	decoder := NewDecoder(bytes.NewReader(CreateRequest("system.listMethods", nil)))
+	decoder.MaxStringLength = 10
+
+	_, _, err := decoder.DecodeRequest()
+
+	var limitErr *LimitExceeded
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxStringLength" {
+		t.Fatalf("Expected MaxStringLength exceeded, got %v", err)
+	}
+}
+
+func TestDecodeSplitText(t *testing.T) {
+	document := xml.Header + "<methodResponse><params><param><value><string>" +
+		strings.Repeat("<![CDATA[ab]]>", 50000) + "</string></value></param></params></methodResponse>"
+
+	decoder := NewDecoder(strings.NewReader(document))
+	params, err := decoder.DecodeResponseParams()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	str, err := params[0].AsString()
+	if err != nil || str != strings.Repeat("ab", 50000) {
+		t.Fatalf("Expected %d bytes, got %d (%v)", 100000, len(str), err)
+	}
+
+	err = decodeLimited(document, DecodeOptions{MaxStringLength: 99999})
+
+	var limitErr *LimitExceeded
+	if !errors.As(err, &limitErr) || limitErr.Limit != "MaxStringLength" {
+		t.Fatalf("Expected MaxStringLength exceeded, got %v", err)
+	}
+}
+
+func TestStrictValid(t *testing.T) {
+	document := xml.Header + `<methodResponse xmlns:ex="` + ExtensionsNamespace + `">
+  <params><param><value><struct>
+    <member><name>int</name><value><i4>-12</i4></value></member>
+    <member><name>boolean</name><value><boolean>1</boolean></value></member>
+    <member><name>string</name><value>main</value></member>
+    <member><name>double</name><value><double>-12.214</double></value></member>
+    <member><name>dateTime</name><value><dateTime.iso8601>19980717T14:08:55</dateTime.iso8601></value></member>
+    <member><name>base64</name><value><base64>eW91IGNhbid0IHJlYWQgdGhpcyE=</base64></value></member>
+    <member><name>array</name><value><array><data><value><int>1</int></value></data></array></value></member>
+    <member><name>nil</name><value><ex:nil/></value></member>
+    <member><name>i8</name><value><ex:i8>3000000000</ex:i8></value></member>
+  </struct></value></param></params>
+</methodResponse>`
+
+	decoder := NewDecoder(strings.NewReader(document))
+	decoder.Strict = true
+
+	value, err := decoder.DecodeResponse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(value.Struct) != 9 {
+		t.Fatalf("Expected 9 members, got %s", printValue(value))
+	}
+}
+
+func TestStrictInvalid(t *testing.T) {
+	items := []string{
+		"<value><byte>1</byte></value>",
+		"<value><long>1</long></value>",
+		"<value><dateTime>19980717T14:08:55</dateTime></value>",
+		"<value><nil/></value>",
+		"<value><ex:nil/></value>",
+		`<value><ex:nil xmlns:ex="http://example.com/ex"/></value>`,
+		`<value><ex:int xmlns:ex="` + ExtensionsNamespace + `">1</ex:int></value>`,
+		"<extra/><value><int>1</int></value>",
+		"stray<value><int>1</int></value>",
+		"<value>stray<int>1</int></value>",
+		"<value><int>1</int>stray</value>",
+		"<value><int>1</int><string>a</string></value>",
+		"<value><int><b>1</b></int></value>",
+		"<value><array><data>stray<value><int>1</int></value></data></array></value>",
+		"<value><array><data><int>1</int></data></array></value>",
+		"<value><struct><member>stray<name>a</name><value><int>1</int></value></member></struct></value>",
+		"<value><struct><member><name>a</name></member></struct></value>",
+		"<value><boolean>true</boolean></value>",
+		"<value><double>1e10</double></value>",
+		"<value><double>NaN</double></value>",
+		"<value><dateTime.iso8601>2016-04-07T21:13:58+1200</dateTime.iso8601></value>",
+		"<value><base64>eW91IG</base64></value>"}
+
+	for _, item := range items {
+		document := xml.Header + "<methodResponse><params><param>" + item + "</param></params></methodResponse>"
+
+		decoder := NewDecoder(strings.NewReader(document))
+		decoder.Strict = true
+
+		_, err := decoder.DecodeResponse()
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected parse error for %s, got %v", item, err)
+		}
+
+		if parseErr.Line != 2 || parseErr.Column <= len("<methodResponse><params><param>") {
+			t.Fatalf("Expected position in %s, got line %d column %d", item, parseErr.Line, parseErr.Column)
+		}
+	}
+
+	documents := []string{
+		"<methodCall><methodName>d.get name</methodName></methodCall>",
+		"<methodCall><methodName>d.name()</methodName></methodCall>",
+		"<methodCall><methodName>echo</methodName></methodCall>stray",
+		"<methodCall><methodName>echo</methodName></methodCall><methodCall/>",
+		"<methodResponse><params></params></methodResponse>stray",
+		"<methodResponse><params></params><params></params></methodResponse>",
+		"<methodResponse><params></params></methodResponse><extra/>",
+		"<methodResponse><fault><value><struct><member><name>faultCode</name><value><int>4</int></value></member>" +
+			"<member><name>faultString</name><value><string>a</string></value></member></struct></value>stray</fault></methodResponse>",
+		"<methodResponse><fault><value><struct><member><name>faultCode</name><value><int>4</int></value></member>" +
+			"<member><name>faultString</name><value><string>a</string></value></member></struct></value></fault></methodResponse><extra/>"}
+
+	for _, item := range documents {
+		decoder := NewDecoder(strings.NewReader(xml.Header + item))
+		decoder.Strict = true
+
+		var err error
+		if strings.HasPrefix(item, "<methodCall>") {
+			_, _, err = decoder.DecodeRequest()
+		} else {
+			_, err = decoder.DecodeResponseParams()
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected parse error for %s, got %v", item, err)
+		}
+
+		if parseErr.Line != 2 || parseErr.Column <= len("<methodCall><methodName>") {
+			t.Fatalf("Expected position in %s, got line %d column %d", item, parseErr.Line, parseErr.Column)
+		}
+
+		decoder = NewDecoder(strings.NewReader(xml.Header + item))
+		if strings.HasPrefix(item, "<methodCall>") {
+			_, _, err = decoder.DecodeRequest()
+		} else {
+			_, err = decoder.DecodeResponseParams()
+		}
+
+		if errors.As(err, &parseErr) {
+			t.Fatalf("Unexpected error for %s without Strict: %s", item, err)
+		}
+	}
 }
 
 func TestCreateRequest(t *testing.T) {
@@ -931,7 +1375,7 @@ func TestCreateRequestArrayParam(t *testing.T) {
 	xmlValues, values := arrayValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Array Test</methodName><params>" +
+		exMethodCall + "<methodName>Array Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
@@ -942,7 +1386,7 @@ func TestCreateRequestNilParam(t *testing.T) {
 	xmlValues, values := nilValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Nil Test</methodName><params>" +
+		exMethodCall + "<methodName>Nil Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
@@ -953,7 +1397,7 @@ func TestCreateRequestByteParam(t *testing.T) {
 	xmlValues, values := byteValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Byte Test</methodName><params>" +
+		exMethodCall + "<methodName>Byte Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
@@ -964,7 +1408,7 @@ func TestCreateRequestFloatParam(t *testing.T) {
 	xmlValues, values := floatValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Float Test</methodName><params>" +
+		exMethodCall + "<methodName>Float Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
@@ -975,7 +1419,7 @@ func TestCreateRequestLongParam(t *testing.T) {
 	xmlValues, values := longValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Long Test</methodName><params>" +
+		exMethodCall + "<methodName>Long Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
@@ -986,13 +1430,237 @@ func TestCreateRequestShortParam(t *testing.T) {
 	xmlValues, values := shortValidData()
 
 	expected := xml.Header +
-		"<methodCall><methodName>Short Test</methodName><params>" +
+		exMethodCall + "<methodName>Short Test</methodName><params>" +
 		formatParamValues(xmlValues) +
 		"</params></methodCall>"
 
 	createCompareRequest("Short Test", values, expected, t)
 }
 
+func TestCreateRequestStructParam(t *testing.T) {
+	xmlValues, values := structValidData()
+
+	expected := xml.Header +
+		exMethodCall + "<methodName>Struct Test</methodName><params>" +
+		formatParamValues(xmlValues) +
+		"</params></methodCall>"
+
+	createCompareRequest("Struct Test", values, expected, t)
+}
+
+func TestCreateResponse(t *testing.T) {
+	xmlValues, values := stringValidData()
+
+	expected := xml.Header +
+		"<methodResponse><params>" +
+		formatParamValues(xmlValues[:1]) +
+		"</params></methodResponse>"
+
+	actual := string(CreateResponse(values[0]))
+	if actual != expected {
+		t.Fatalf("Expected document: %s\ngot: %s\n", expected, actual)
+	}
+
+	value, err := ParseResponse(bytes.NewBufferString(actual))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	compareValue(&values[0], value, t)
+}
+
+func TestCreateFault(t *testing.T) {
+	expected := xml.Header +
+		"<methodResponse><fault><value><struct>" +
+		"<member><name>faultCode</name><value><int>-32601</int></value></member>" +
+		"<member><name>faultString</name><value><string>Method &#39;what&#39; not defined</string></value></member>" +
+		"</struct></value></fault></methodResponse>"
+
+	actual := string(CreateFault(FaultMethodNotFound, "Method 'what' not defined"))
+	if actual != expected {
+		t.Fatalf("Expected document: %s\ngot: %s\n", expected, actual)
+	}
+
+	_, err := ParseResponse(bytes.NewBufferString(actual))
+
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expected fault, got %v", err)
+	}
+
+	if fault.Code != FaultMethodNotFound || fault.String != "Method 'what' not defined" {
+		t.Fatalf("Unexpected fault %#v", *fault)
+	}
+}
+
+func TestDecodeResponseReader(t *testing.T) {
+	values := make([]Value, 1000)
+	for i := range values {
+		values[i] = NewArray([]Value{NewString("hash" + strconv.Itoa(i)), NewLong(int64(i) << 32)})
+	}
+
+	expected := NewArray(values)
+	reader := iotest.OneByteReader(bytes.NewReader(CreateResponse(expected)))
+
+	actual, err := NewDecoder(reader).DecodeResponse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	compareValue(&expected, actual, t)
+}
+
+func TestParseResponseParams(t *testing.T) {
+	document := xml.Header + "<methodResponse><params>" +
+		"<param><value><int>1</int></value></param>" +
+		"<param><value><string>two</string></value></param>" +
+		"<param><value><array><data></data></array></value></param>" +
+		"</params></methodResponse>"
+
+	expecteds := []Value{NewInt(1), NewString("two"), NewArray([]Value{})}
+
+	actuals, err := ParseResponseParams(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(actuals) != len(expecteds) {
+		t.Fatalf("Expected count %v values, got %v", len(expecteds), len(actuals))
+	}
+
+	for i, expected := range expecteds {
+		compareValue(&expected, &actuals[i], t)
+	}
+
+	var parseErr *ParseError
+	if _, err = ParseResponse(bytes.NewBufferString(document)); !errors.As(err, &parseErr) {
+		t.Fatalf("Expected error for extra params, got %v", err)
+	}
+
+	decoder := NewDecoder(strings.NewReader(document))
+	decoder.AllowExtraParams = true
+
+	actual, err := decoder.DecodeResponse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	compareValue(&expecteds[0], actual, t)
+}
+
+func TestParseResponseNoParams(t *testing.T) {
+	document := xml.Header + "<methodResponse><params></params></methodResponse>"
+
+	actuals, err := ParseResponseParams(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(actuals) != 0 {
+		t.Fatalf("Expected no values, got %v", len(actuals))
+	}
+
+	if _, err = ParseResponse(bytes.NewBufferString(document)); err == nil {
+		t.Fatalf("Expected error for missing param")
+	}
+}
+
+func runParseRequest(document string, expectedName string, expecteds []Value, t *testing.T) {
+	methodName, actuals, err := ParseRequest(strings.NewReader(document))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if methodName != expectedName {
+		t.Fatalf("Expected %s, got %s", expectedName, methodName)
+	}
+
+	if len(actuals) != len(expecteds) {
+		t.Fatalf("Expected count %v values, got %v", len(expecteds), len(actuals))
+	}
+
+	for i, expected := range expecteds {
+		actual := actuals[i]
+
+		compareValue(&expected, &actual, t)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	_, values := mixedArrayData()
+	runParseRequest(string(CreateRequest("d.multicall", values)), "d.multicall", values, t)
+}
+
+func TestParseRequestStructParams(t *testing.T) {
+	_, structs := structValidData()
+	_, nested := structData()
+
+	values := append(structs, nested...)
+	for depth := 0; depth <= 6; depth++ {
+		values = append(values, nestedStructData(depth))
+	}
+
+	runParseRequest(string(CreateRequest("structs", values)), "structs", values, t)
+
+	for i := range values {
+		actual, err := ParseResponseParams(bytes.NewReader(CreateResponse(values[i])))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(actual) != 1 {
+			t.Fatalf("Expected 1 value, got %d", len(actual))
+		}
+
+		compareValue(&values[i], &actual[0], t)
+	}
+}
+
+func TestParseRequestScalarParams(t *testing.T) {
+	_, integers := integerValidData()
+	_, strs := stringValidData()
+	_, longs := longValidData()
+
+	values := append(append(integers, strs...), longs...)
+	runParseRequest(string(CreateRequest("scalars", values)), "scalars", values, t)
+}
+
+func TestParseRequestNoParams(t *testing.T) {
+	runParseRequest(string(CreateRequest("system.client_version", nil)), "system.client_version", nil, t)
+	runParseRequest(xml.Header+"<methodCall>\n <methodName>system.listMethods</methodName>\n <params/>\n</methodCall>", "system.listMethods", nil, t)
+}
+
+func TestParseRequestMalformed(t *testing.T) {
+	documents := []string{
+		"",
+		"<methodResponse></methodResponse>",
+		"<methodCall><params></params></methodCall>",
+		"<methodCall><methodName></methodName></methodCall>",
+		"<methodCall><methodName>a</methodName><params><param></param></params></methodCall>",
+		"<methodCall><methodName>a</methodName><params><value><int>1</int></value></params></methodCall>",
+		"<methodCall><methodName>a</methodName><params><param><value><bad>1</bad></value></param></params></methodCall>",
+		"<methodCall>\n<methodName>a</methodName>\n<params>\n<param><value><int>1</int>\n</params></methodCall>"}
+
+	for _, document := range documents {
+		_, _, err := ParseRequest(strings.NewReader(document))
+
+		var parseErr *ParseError
+		var syntaxErr *xml.SyntaxError
+
+		if errors.As(err, &parseErr) {
+			if parseErr.Line < 1 || parseErr.Column < 1 {
+				t.Fatalf("Expected position for %q, got %s", document, err)
+			}
+		} else if errors.As(err, &syntaxErr) {
+			if syntaxErr.Line < 1 {
+				t.Fatalf("Expected position for %q, got %s", document, err)
+			}
+		} else {
+			t.Fatalf("Expected positioned error for %q, got %v", document, err)
+		}
+	}
+}
+
 // JSON
 
 func integerJsonData() (jsonDoc []string, values []Value) {
@@ -1296,7 +1964,7 @@ func longJsonData() (jsonDoc []string, values []Value) {
 			`{"long":-92233720368547758089223372036854775808}`, //-overflow
 			`{"i8":"invalid"}`},                                //invalid
 		[]Value{
-			NewLong(-9223372036854775808),
+			NewLong(9223372036854775807),
 			NewLong(-9223372036854775808),
 			NewLong(0),
 			NewLong(-1),
@@ -1365,6 +2033,35 @@ func mixedArrayJsonData() (jsonDoc []string, values []Value) {
 			NewString("Hello World & \"You\"!")}}}
 }
 
+func structJsonData() (jsonDoc []string, values []Value) {
+	return []string{
+			`{"struct":{"name":{"string":"main"},"size":{"i4":42}}}`,
+			`{"struct":[{"name":"name","value":{"string":"main"}},{"name":"size","value":{"i4":42}}]}`,
+			`{"struct":{}}`,
+			`{"struct":[]}`,
+			`{"struct":{
+      "files":{"array":[
+        {"struct":[{"name":"path","value":{"string":"a.txt"}}]},
+        {"struct":{"path":{"string":"b.txt"},"meta":{"struct":{"done":{"boolean":true}}}}}]},
+      "empty":{"string":""}}}`},
+		[]Value{
+			NewStruct([]Member{
+				{Name: "name", Value: NewString("main")},
+				{Name: "size", Value: NewInt(42)}}),
+			NewStruct([]Member{
+				{Name: "name", Value: NewString("main")},
+				{Name: "size", Value: NewInt(42)}}),
+			NewStruct([]Member{}),
+			NewStruct([]Member{}),
+			NewStruct([]Member{
+				{Name: "files", Value: NewArray([]Value{
+					NewStruct([]Member{{Name: "path", Value: NewString("a.txt")}}),
+					NewStruct([]Member{
+						{Name: "path", Value: NewString("b.txt")},
+						{Name: "meta", Value: NewStruct([]Member{{Name: "done", Value: NewBoolean(true)}})}})})},
+				{Name: "empty", Value: NewString("")}})}
+}
+
 func TestIntegerJsonParam(t *testing.T) {
 	jsonDoc, values := integerJsonData()
 	runParseJsonRequest(jsonDoc, values, t)
@@ -1430,6 +2127,132 @@ func TestMixedArrayJsonParam(t *testing.T) {
 	runParseJsonRequest(jsonDoc, values, t)
 }
 
+func TestStructJsonParam(t *testing.T) {
+	jsonDoc, values := structJsonData()
+	runParseJsonRequest(jsonDoc, values, t)
+}
+
+func TestInvalidStructJsonParam(t *testing.T) {
+	items := []string{
+		`{"struct":"main"}`,
+		`{"struct":{"name":"main"}}`,
+		`{"struct":[{"name":"name","size":{"i4":42}}]}`,
+		`{"struct":[{"name":42,"value":{"i4":42}}]}`,
+		`{"struct":{"name":{"string":"main"}`}
+
+	for _, item := range items {
+		body := strings.NewReader(`{"methodName":"helloMethod","params":[` + item + `]}`)
+
+		if _, _, err := ParseJsonRequest(body); err == nil {
+			t.Fatalf("Expected error for %s", item)
+		}
+	}
+}
+
+// HTTP
+
+func newXmlServer(contentType string, status int, body string, t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST, got %s", r.Method)
+		}
+		if r.Header.Get("Content-Type") != "text/xml" {
+			t.Errorf("Expected text/xml, got %s", r.Header.Get("Content-Type"))
+		}
+
+		request, _ := io.ReadAll(r.Body)
+		expected := string(CreateRequest("system.client_version", []Value{NewString("main")}))
+		if string(request) != expected {
+			t.Errorf("Expected request %s, got %s", expected, request)
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+}
+
+func TestClientCall(t *testing.T) {
+	server := newXmlServer("text/xml; charset=utf-8", http.StatusOK,
+		xml.Header+"<methodResponse><params><param><value><string>0.9.8</string></value></param></params></methodResponse>", t)
+	defer server.Close()
+
+	actual, err := NewClient(server.URL).Call(context.Background(), "system.client_version", NewString("main"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewString("0.9.8")
+	compareValue(&expected, actual, t)
+}
+
+func TestClientCallBadStatus(t *testing.T) {
+	server := newXmlServer("text/xml", http.StatusInternalServerError, "", t)
+	defer server.Close()
+
+	if _, err := NewClient(server.URL).Call(context.Background(), "system.client_version", NewString("main")); err == nil {
+		t.Fatalf("Expected error for bad status")
+	}
+}
+
+//Records the request and answers with an empty string
+type recordingTransport struct {
+	request []byte
+	length  int64
+}
+
+func (t *recordingTransport) RoundTrip(ctx context.Context, request io.Reader, length int64) (response io.ReadCloser, err error) {
+	if t.request, err = io.ReadAll(request); err != nil {
+		return nil, err
+	}
+	t.length = length
+
+	return io.NopCloser(bytes.NewReader(CreateResponse(NewString("")))), nil
+}
+
+func TestClientCallStreamsRequest(t *testing.T) {
+	transport := &recordingTransport{}
+	client := &Client{Transport: transport}
+
+	data := bytes.Repeat([]byte{0, 1, 2, 253, 254, 255}, 100000)
+	params := []Value{NewString("main"), NewBase64Bytes(data)}
+
+	if _, err := client.Call(context.Background(), "d.upload", params...); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := CreateRequest("d.upload", params)
+	if !bytes.Equal(transport.request, expected) {
+		t.Fatalf("Expected request of %d bytes, got %d", len(expected), len(transport.request))
+	}
+
+	if transport.length != int64(len(expected)) {
+		t.Fatalf("Expected length %d, got %d", len(expected), transport.length)
+	}
+}
+
+func TestClientCallEncodeError(t *testing.T) {
+	transport := &recordingTransport{}
+	client := &Client{Transport: transport}
+
+	if _, err := client.Call(context.Background(), "d.upload", Value{}); err == nil {
+		t.Fatalf("Expected error for value without a type")
+	}
+
+	if transport.request != nil {
+		t.Fatalf("Expected no request, got %s", transport.request)
+	}
+}
+
+func TestClientCallBadContentType(t *testing.T) {
+	server := newXmlServer("text/html", http.StatusOK, "<html></html>", t)
+	defer server.Close()
+
+	if _, err := NewClient(server.URL).Call(context.Background(), "system.client_version", NewString("main")); err == nil {
+		t.Fatalf("Expected error for bad content type")
+	}
+}
+
 /*
 func TestParseJsonRequest(t *testing.T) {
   json := `{"methodName":"system.client_version"}`
@@ -0,0 +1,42 @@
+package xmlrpc
+
+import (
+	"errors"
+	"time"
+)
+
+//Layouts for encoding dateTime.iso8601 values, see EncodeOptions
+const (
+	DateTimeBasic    = "20060102T15:04:05"         //as in the specification, without a zone
+	DateTimeExtended = "2006-01-02T15:04:05-07:00" //RFC 3339
+)
+
+var dateTimeLayouts = dateLayouts()
+
+//Every combination of basic or extended date and time, with or without a
+//zone. Fractional seconds are accepted by time.Parse without being in the layout.
+func dateLayouts() (layouts []string) {
+	for _, date := range []string{"20060102", "2006-01-02"} {
+		for _, clock := range []string{"15:04:05", "150405"} {
+			for _, zone := range []string{"", "Z07:00", "Z0700", "Z07"} {
+				layouts = append(layouts, date+"T"+clock+zone)
+			}
+		}
+	}
+	return layouts
+}
+
+//Parses the common dateTime.iso8601 variants, using loc for values without a zone
+func parseDateTime(str string, loc *time.Location) (t time.Time, err error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	for _, layout := range dateTimeLayouts {
+		if t, err = time.ParseInLocation(layout, str, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("Unrecognised dateTime " + str)
+}
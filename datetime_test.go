@@ -0,0 +1,96 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDateTime(t *testing.T) {
+	items := []string{
+		"20160407T21:13:58",
+		"20160407T211358",
+		"2016-04-07T21:13:58",
+		"2016-04-07T21:13:58Z",
+		"20160407T21:13:58Z",
+		"2016-04-07T21:13:58.123Z",
+		"2016-04-07T21:13:58+12:00",
+		"2016-04-07T21:13:58+1200",
+		"2016-04-07T21:13:58+12",
+		"20160407T21:13:58.5-05:30"}
+	expecteds := []time.Time{
+		time.Date(2016, 4, 7, 21, 13, 58, 0, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 123000000, time.UTC),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, NZ),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, NZ),
+		time.Date(2016, 4, 7, 21, 13, 58, 0, NZ),
+		time.Date(2016, 4, 7, 21, 13, 58, 500000000, time.FixedZone("", -(5*3600+1800)))}
+
+	for i, item := range items {
+		actual, err := parseDateTime(item, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", item, err)
+		}
+
+		if !actual.Equal(expecteds[i]) {
+			t.Fatalf("Expected %s for %s, got %s", expecteds[i], item, actual)
+		}
+	}
+
+	for _, item := range []string{"", "2016-04-07", "21:13:58", "2016-04-07 21:13:58", "yesterday"} {
+		if _, err := parseDateTime(item, nil); err == nil {
+			t.Fatalf("Expected error for %q", item)
+		}
+	}
+}
+
+func TestDecodeDateTimeLocation(t *testing.T) {
+	document := xml.Header + "<methodResponse><params><param><value>" +
+		"<array><data>" +
+		"<value><dateTime.iso8601>20160407T21:13:58</dateTime.iso8601></value>" +
+		"<value><dateTime.iso8601>20160407T09:13:58Z</dateTime.iso8601></value>" +
+		"</data></array>" +
+		"</value></param></params></methodResponse>"
+
+	decoder := NewDecoder(strings.NewReader(document))
+	decoder.Location = NZ
+
+	actual, err := decoder.DecodeResponse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewArray([]Value{
+		NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
+		NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ))})
+	compareValue(&expected, actual, t)
+}
+
+func TestEncodeDateTimeLayout(t *testing.T) {
+	value := NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ))
+
+	layouts := []string{"", DateTimeBasic, DateTimeExtended}
+	expecteds := []string{"2016-04-07T21:13:58+1200", "20160407T21:13:58", "2016-04-07T21:13:58+12:00"}
+
+	for i, layout := range layouts {
+		var buf bytes.Buffer
+
+		encoder := NewEncoder(&buf)
+		encoder.DateTimeLayout = layout
+
+		if err := encoder.EncodeResponse(value); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := "<dateTime.iso8601>" + expecteds[i] + "</dateTime.iso8601>"
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("Expected %s in %s", expected, buf.String())
+		}
+	}
+}
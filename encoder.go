@@ -0,0 +1,258 @@
+package xmlrpc
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/literatesnow/xmlrpc/util"
+)
+
+//How the Apache extension types, such as nil and i8, are written
+type Extensions int
+
+const (
+	ExtensionsApache Extensions = iota //<ex:nil/>, declaring ExtensionsNamespace on the root element
+	ExtensionsBare                     //<nil/>, without a namespace
+	ExtensionsNone                     //specification types only, failing for values without one
+)
+
+//Options controlling how documents are encoded
+type EncodeOptions struct {
+	DateTimeLayout string     //such as DateTimeBasic or DateTimeExtended, 2006-01-02T15:04:05-0700 if empty
+	Extensions     Extensions //ExtensionsApache if zero
+}
+
+//Writes XML-RPC documents to a stream
+type Encoder struct {
+	EncodeOptions
+	writer  io.Writer
+	encoder *xml.Encoder
+}
+
+func NewEncoder(writer io.Writer) *Encoder {
+	return &Encoder{writer: writer, encoder: xml.NewEncoder(writer)}
+}
+
+//Writes a <methodCall> document
+func (e *Encoder) EncodeRequest(methodName string, params []Value) (err error) {
+	return e.document("methodCall", e.namespaces(params), func() (err error) {
+		if err = util.Text(e.encoder, "methodName", methodName); err != nil {
+			return err
+		}
+		return e.params(params)
+	})
+}
+
+//Writes a <methodResponse> document returning value
+func (e *Encoder) EncodeResponse(value Value) (err error) {
+	values := []Value{value}
+
+	return e.document("methodResponse", e.namespaces(values), func() (err error) {
+		return e.params(values)
+	})
+}
+
+//Writes a <methodResponse> document containing a <fault>
+func (e *Encoder) EncodeFault(code int, message string) (err error) {
+	value := faultValue(&Fault{Code: code, String: message})
+
+	return e.document("methodResponse", nil, func() (err error) {
+		if err = util.Start(e.encoder, "fault"); err != nil {
+			return err
+		}
+		if err = e.value(&value); err != nil {
+			return err
+		}
+		return util.End(e.encoder, "fault")
+	})
+}
+
+func (e *Encoder) document(name string, attrs []xml.Attr, write func() error) (err error) {
+	if _, err = io.WriteString(e.writer, xml.Header); err != nil {
+		return err
+	}
+
+	if err = util.Start(e.encoder, name, attrs...); err != nil {
+		return err
+	}
+
+	if err = write(); err != nil {
+		return err
+	}
+
+	if err = util.End(e.encoder, name); err != nil {
+		return err
+	}
+
+	return e.encoder.Flush()
+}
+
+func (e *Encoder) params(values []Value) (err error) {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err = util.Start(e.encoder, "params"); err != nil {
+		return err
+	}
+
+	for i := range values {
+		if err = util.Start(e.encoder, "param"); err != nil {
+			return err
+		}
+		if err = e.value(&values[i]); err != nil {
+			return err
+		}
+		if err = util.End(e.encoder, "param"); err != nil {
+			return err
+		}
+	}
+
+	return util.End(e.encoder, "params")
+}
+
+func (e *Encoder) value(v *Value) (err error) {
+	if v.Kind() == KindInvalid {
+		return errors.New("Cannot encode value without a type")
+	}
+
+	if err = util.Start(e.encoder, "value"); err != nil {
+		return err
+	}
+
+	if e.Extensions == ExtensionsNone {
+		if v, err = withoutExtensions(v); err != nil {
+			return err
+		}
+	}
+
+	dataType, text := v.asString()
+
+	if v.DateTime != nil && e.DateTimeLayout != "" {
+		text = v.DateTime.Format(e.DateTimeLayout)
+	}
+
+	if e.Extensions == ExtensionsBare {
+		dataType = strings.TrimPrefix(dataType, "ex:")
+	}
+
+	switch dataType {
+	case "ex:nil", "nil":
+		err = util.Empty(e.encoder, dataType)
+	case "array":
+		err = e.array(v.Array)
+	case "struct":
+		err = e.structMembers(v.Struct)
+	default:
+		err = util.Text(e.encoder, dataType, text)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return util.End(e.encoder, "value")
+}
+
+func (e *Encoder) array(values []Value) (err error) {
+	if err = util.Start(e.encoder, "array"); err != nil {
+		return err
+	}
+	if err = util.Start(e.encoder, "data"); err != nil {
+		return err
+	}
+
+	for i := range values {
+		if err = e.value(&values[i]); err != nil {
+			return err
+		}
+	}
+
+	if err = util.End(e.encoder, "data"); err != nil {
+		return err
+	}
+	return util.End(e.encoder, "array")
+}
+
+func (e *Encoder) structMembers(members []Member) (err error) {
+	if err = util.Start(e.encoder, "struct"); err != nil {
+		return err
+	}
+
+	for i := range members {
+		if err = util.Start(e.encoder, "member"); err != nil {
+			return err
+		}
+		if err = util.Text(e.encoder, "name", members[i].Name); err != nil {
+			return err
+		}
+		if err = e.value(&members[i].Value); err != nil {
+			return err
+		}
+		if err = util.End(e.encoder, "member"); err != nil {
+			return err
+		}
+	}
+
+	return util.End(e.encoder, "struct")
+}
+
+//Declares the ex prefix if any of values need it
+func (e *Encoder) namespaces(values []Value) (attrs []xml.Attr) {
+	if e.Extensions != ExtensionsApache || !usesExtensions(values) {
+		return nil
+	}
+
+	return []xml.Attr{{Name: xml.Name{Local: "xmlns:ex"}, Value: ExtensionsNamespace}}
+}
+
+func usesExtensions(values []Value) bool {
+	for i := range values {
+		switch values[i].Kind() {
+		case KindNil, KindByte, KindFloat, KindLong, KindShort:
+			return true
+		case KindArray:
+			if usesExtensions(values[i].Array) {
+				return true
+			}
+		case KindStruct:
+			for j := range values[i].Struct {
+				if usesExtensions([]Value{values[i].Struct[j].Value}) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+//Converts an extension type to the specification type holding the same
+//value, failing if there isn't one
+func withoutExtensions(v *Value) (value *Value, err error) {
+	var converted Value
+
+	switch v.Kind() {
+	case KindByte:
+		converted = NewInt(int32(*v.Byte))
+	case KindShort:
+		converted = NewInt(int32(*v.Short))
+	case KindFloat:
+		converted = NewDouble(float64(*v.Float))
+	case KindLong:
+		if *v.Long < math.MinInt32 || *v.Long > math.MaxInt32 {
+			return nil, errors.New("Cannot encode i8 " + strconv.FormatInt(*v.Long, 10) + " without extensions")
+		}
+		converted = NewInt(int32(*v.Long))
+	case KindNil:
+		return nil, errors.New("Cannot encode nil without extensions")
+	default:
+		return v, nil
+	}
+
+	return &converted, nil
+}
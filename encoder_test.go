@@ -0,0 +1,211 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type limitWriter struct {
+	remaining int
+}
+
+func (w *limitWriter) Write(p []byte) (n int, err error) {
+	if len(p) > w.remaining {
+		n = w.remaining
+		w.remaining = 0
+		return n, errors.New("Writer full")
+	}
+	w.remaining -= len(p)
+	return len(p), nil
+}
+
+func TestEncodeRequest(t *testing.T) {
+	_, values := mixedArrayData()
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).EncodeRequest("d.multicall", values); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	methodName, actuals, err := ParseRequest(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if methodName != "d.multicall" {
+		t.Fatalf("Expected d.multicall, got %s", methodName)
+	}
+
+	compareValue(&values[0], &actuals[0], t)
+}
+
+func TestEncodeMultipleDocuments(t *testing.T) {
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+
+	if err := encoder.EncodeResponse(NewInt(1)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := encoder.EncodeFault(1, "Failed"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := string(CreateResponse(NewInt(1))) + string(CreateFault(1, "Failed"))
+	if buf.String() != expected {
+		t.Fatalf("Expected document: %s\ngot: %s\n", expected, buf.String())
+	}
+}
+
+func TestEncodeWriteError(t *testing.T) {
+	sizes := []int{0, 10, 100}
+	for _, size := range sizes {
+		_, values := structData()
+		if err := NewEncoder(&limitWriter{remaining: size}).EncodeResponse(values[0]); err == nil {
+			t.Fatalf("Expected error writing %d bytes", size)
+		}
+	}
+}
+
+func encodeWithExtensions(extensions Extensions, value Value) (document string, err error) {
+	var buf bytes.Buffer
+
+	encoder := NewEncoder(&buf)
+	encoder.Extensions = extensions
+
+	err = encoder.EncodeResponse(value)
+	return buf.String(), err
+}
+
+func TestEncodeExtensions(t *testing.T) {
+	value := NewArray([]Value{NewNil(), NewLong(3000000000), NewInt(1)})
+
+	modes := []Extensions{ExtensionsApache, ExtensionsBare}
+	expecteds := []string{
+		`<methodResponse xmlns:ex="` + ExtensionsNamespace + `"><params><param><value><array><data>` +
+			`<value><ex:nil></ex:nil></value><value><ex:i8>3000000000</ex:i8></value><value><int>1</int></value>`,
+		`<methodResponse><params><param><value><array><data>` +
+			`<value><nil></nil></value><value><i8>3000000000</i8></value><value><int>1</int></value>`}
+
+	for i, mode := range modes {
+		document, err := encodeWithExtensions(mode, value)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if !strings.Contains(document, expecteds[i]) {
+			t.Fatalf("Expected %s in %s", expecteds[i], document)
+		}
+
+		actual, err := ParseResponse(bytes.NewBufferString(document))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		compareValue(&value, actual, t)
+	}
+
+	document, _ := encodeWithExtensions(ExtensionsApache, NewInt(1))
+	if strings.Contains(document, "xmlns") {
+		t.Fatalf("Expected no namespace declaration in %s", document)
+	}
+}
+
+func TestEncodeWithoutExtensions(t *testing.T) {
+	values := []Value{NewByte(255), NewShort(-32768), NewFloat(0.5), NewLong(-2147483648), NewLong(2147483647)}
+	expecteds := []Value{NewInt(255), NewInt(-32768), NewDouble(0.5), NewInt(-2147483648), NewInt(2147483647)}
+
+	document, err := encodeWithExtensions(ExtensionsNone, NewArray(values))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	decoder := NewDecoder(strings.NewReader(document))
+	decoder.Strict = true
+
+	actual, err := decoder.DecodeResponse()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewArray(expecteds)
+	compareValue(&expected, actual, t)
+
+	for _, value := range []Value{NewNil(), NewLong(2147483648), NewStruct([]Member{{Name: "a", Value: NewLong(-2147483649)}})} {
+		if _, err := encodeWithExtensions(ExtensionsNone, value); err == nil {
+			t.Fatalf("Expected error for %s", printValue(&value))
+		}
+	}
+}
+
+func TestDecodeExtensionsNamespace(t *testing.T) {
+	document := xml.Header + `<methodResponse xmlns:apache="` + ExtensionsNamespace + `"><params><param><value>` +
+		`<array><data><value><apache:i8>3000000000</apache:i8></value><value><apache:nil/></value></data></array>` +
+		`</value></param></params></methodResponse>`
+
+	for _, strict := range []bool{false, true} {
+		decoder := NewDecoder(strings.NewReader(document))
+		decoder.Strict = strict
+
+		actual, err := decoder.DecodeResponse()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		expected := NewArray([]Value{NewLong(3000000000), NewNil()})
+		compareValue(&expected, actual, t)
+	}
+
+	items := []string{
+		`<x:i8 xmlns:x="http://example.com/ex">1</x:i8>`,
+		`<x:int xmlns:x="` + ExtensionsNamespace + `">1</x:int>`}
+
+	for _, item := range items {
+		_, err := ParseResponse(bytes.NewBufferString(xml.Header +
+			"<methodResponse><params><param><value>" + item + "</value></param></params></methodResponse>"))
+		if err == nil {
+			t.Fatalf("Expected error for %s", item)
+		}
+	}
+}
+
+func TestClientCallWithoutExtensions(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	client.EncodeOptions.Extensions = ExtensionsNone
+
+	actual, err := client.Call(context.Background(), "echo", NewLong(1), NewFloat(0.5))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewArray([]Value{NewInt(1), NewDouble(0.5)})
+	compareValue(&expected, actual, t)
+
+	if _, err = client.Call(context.Background(), "echo", NewNil()); err == nil {
+		t.Fatalf("Expected error for nil without extensions")
+	}
+}
+
+func TestEncodeInvalidValue(t *testing.T) {
+	values := []Value{{}, NewArray([]Value{NewInt(1), {}}), NewStruct([]Member{{Name: "a"}})}
+
+	for _, value := range values {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf).EncodeResponse(value); err == nil {
+			t.Fatalf("Expected error encoding %s", printValue(&value))
+		}
+	}
+
+	expected := NewArray([]Value{})
+	actual, err := ParseResponse(bytes.NewBuffer(CreateResponse(NewArray(nil))))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	compareValue(&expected, actual, t)
+}
@@ -0,0 +1,61 @@
+package xmlrpc
+
+import (
+	"strconv"
+	"strings"
+)
+
+//Malformed document, with the position the problem was found at
+type ParseError struct {
+	Line   int
+	Column int
+	Path   string //elements leading to the problem, such as methodResponse/params/param/value/int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Msg
+	if e.Path != "" {
+		msg += " in " + e.Path
+	}
+	return msg + " at line " + strconv.Itoa(e.Line) + ", column " + strconv.Itoa(e.Column)
+}
+
+func (d *Decoder) parseError(msg string) (err error) {
+	line, column := d.decoder.InputPos()
+	return &ParseError{Line: line, Column: column, Path: strings.Join(d.path, "/"), Msg: msg}
+}
+
+//Document exceeding one of the limits set in DecodeOptions
+type LimitExceeded struct {
+	Limit string //name of the DecodeOptions field, such as MaxDepth
+	Max   int64
+	Path  string //elements leading to the problem
+}
+
+func (e *LimitExceeded) Error() string {
+	msg := e.Limit + " of " + strconv.FormatInt(e.Max, 10) + " exceeded"
+	if e.Path != "" {
+		msg += " in " + e.Path
+	}
+	return msg
+}
+
+func (d *Decoder) limitExceeded(limit string, max int64) (err error) {
+	return &LimitExceeded{Limit: limit, Max: max, Path: strings.Join(d.path, "/")}
+}
+
+func (d *Decoder) checkString(length int) (err error) {
+	if d.MaxStringLength > 0 && length > d.MaxStringLength {
+		return d.limitExceeded("MaxStringLength", int64(d.MaxStringLength))
+	}
+	return nil
+}
+
+//Checks another element can be added to count existing ones
+func (d *Decoder) checkElements(count int) (err error) {
+	if d.MaxElements > 0 && count >= d.MaxElements {
+		return d.limitExceeded("MaxElements", int64(d.MaxElements))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package xmlrpc
+
+import (
+	"errors"
+	"strconv"
+)
+
+//Fault codes from the specification for fault code interoperability
+const (
+	FaultParseError       = -32700
+	FaultInvalidRequest   = -32600
+	FaultMethodNotFound   = -32601
+	FaultInvalidParams    = -32602
+	FaultInternalError    = -32603
+	FaultApplicationError = -32500
+	FaultSystemError      = -32400
+	FaultTransportError   = -32300
+)
+
+//Represents xmlrpc <fault> response, returned as an error
+type Fault struct {
+	Code   int
+	String string
+}
+
+func (f *Fault) Error() string {
+	return "Fault " + strconv.Itoa(f.Code) + ": " + f.String
+}
+
+//Converts err to a Fault, using FaultApplicationError unless it already is one
+func errorFault(err error) (fault *Fault) {
+	if errors.As(err, &fault) {
+		return fault
+	}
+
+	return &Fault{Code: FaultApplicationError, String: err.Error()}
+}
+
+func faultValue(fault *Fault) (value Value) {
+	return NewStruct([]Member{
+		{Name: "faultCode", Value: NewInt(int32(fault.Code))},
+		{Name: "faultString", Value: NewString(fault.String)}})
+}
+
+func newFault(value *Value) (fault *Fault, err error) {
+	if value == nil || value.Struct == nil {
+		return nil, errors.New("Fault is not a struct")
+	}
+
+	fault = &Fault{}
+
+	for _, mem := range value.Struct {
+		switch mem.Name {
+		case "faultCode":
+			if fault.Code, err = faultCode(&mem.Value); err != nil {
+				return nil, err
+			}
+		case "faultString":
+			if mem.Value.String != nil {
+				fault.String = *mem.Value.String
+			}
+		}
+	}
+
+	return fault, nil
+}
+
+func faultCode(value *Value) (code int, err error) {
+	if value.Int != nil {
+		return int(*value.Int), nil
+	} else if value.Long != nil {
+		return int(*value.Long), nil
+	} else if value.Short != nil {
+		return int(*value.Short), nil
+	} else if value.Byte != nil {
+		return int(*value.Byte), nil
+	}
+
+	return 0, errors.New("Fault code is not an integer")
+}
@@ -0,0 +1,192 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+//Serves JSON calls over HTTP by forwarding them to an XML-RPC server, so a
+//browser can call methods such as those of rTorrent. Requests are read by
+//ParseJsonRequest and answered by WriteJsonResponse, or their untyped
+//equivalents.
+//
+//Calls in a system.multicall are checked against Allow, but rTorrent's
+//d.multicall2, f.multicall, p.multicall and t.multicall run the commands
+//named in their params, which are not. Any method containing "multicall"
+//is therefore only allowed when listed exactly, and listing one allows
+//every command it can reach, execute included.
+type Gateway struct {
+	Client   *Client   //forwards calls, over HTTP or SCGI
+	Allow    []string  //methods that may be called, a trailing * matches any suffix
+	Untyped  bool      //plain JSON params and results instead of type keys
+	Rules    JsonRules //inferring types of untyped params
+	MaxBytes int64     //request body size, DefaultMaxBytes when zero
+}
+
+//Gateway forwarding calls of the allowed methods to client
+func NewGateway(client *Client, allow ...string) *Gateway {
+	return &Gateway{Client: client, Allow: allow}
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := checkJsonContentType(r.Header.Get("Content-Type")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body := maxBytesReader(w, r, g.MaxBytes)
+
+	var methodName string
+	var params []Value
+	var err error
+
+	if g.Untyped {
+		methodName, params, err = ParseUntypedJsonRequest(body, g.Rules)
+	} else {
+		methodName, params, err = ParseJsonRequest(body)
+	}
+	if err != nil {
+		badRequest(w, err)
+		return
+	}
+
+	status := http.StatusOK
+
+	value, err := g.call(r, methodName, params)
+	if err != nil {
+		var fault *Fault
+		if !errors.As(err, &fault) {
+			err = &Fault{Code: FaultTransportError, String: err.Error()}
+			status = http.StatusBadGateway
+		}
+	}
+
+	var buf bytes.Buffer
+
+	if g.Untyped {
+		err = WriteUntypedJsonResponse(&buf, value, err)
+	} else {
+		err = WriteJsonResponse(&buf, value, err)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+func (g *Gateway) call(r *http.Request, methodName string, params []Value) (value *Value, err error) {
+	if err = g.check(methodName, params); err != nil {
+		return nil, err
+	}
+
+	return g.Client.Call(r.Context(), methodName, params...)
+}
+
+//Checks methodName is allowed, along with every call in a system.multicall
+func (g *Gateway) check(methodName string, params []Value) (err error) {
+	if !g.allowed(methodName) {
+		return &Fault{Code: FaultMethodNotFound, String: "Method '" + methodName + "' not allowed"}
+	}
+
+	if methodName != "system.multicall" {
+		return nil
+	}
+
+	if len(params) != 1 || params[0].Array == nil {
+		return &Fault{Code: FaultInvalidParams, String: "Expecting array of calls"}
+	}
+
+	for i := range params[0].Array {
+		call := &params[0].Array[i]
+
+		//Servers disagree on which of repeated members they use, so only the
+		//checked ones may be present
+		seen := make(map[string]bool, len(call.Struct))
+		for _, member := range call.Struct {
+			if (member.Name != "methodName" && member.Name != "params") || seen[member.Name] {
+				return &Fault{Code: FaultInvalidParams, String: "Unexpected member " + member.Name + " in call " + strconv.Itoa(i)}
+			}
+			seen[member.Name] = true
+		}
+
+		name, err := call.Get("methodName")
+		if err != nil || name.String == nil {
+			return &Fault{Code: FaultInvalidParams, String: "Expecting methodName string in call " + strconv.Itoa(i)}
+		}
+
+		var callParams []Value
+		if value, err := call.Get("params"); err == nil {
+			if callParams, err = value.AsSlice(); err != nil {
+				return &Fault{Code: FaultInvalidParams, String: "Expecting params array in call " + strconv.Itoa(i)}
+			}
+		}
+
+		if err = g.check(*name.String, callParams); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Checks methodName against Allow, only exactly for methods that run other
+//commands
+func (g *Gateway) allowed(methodName string) bool {
+	multicall := strings.Contains(methodName, "multicall")
+
+	for _, pattern := range g.Allow {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if !multicall && strings.HasPrefix(methodName, prefix) {
+				return true
+			}
+		} else if pattern == methodName {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Checks the body is JSON, which a browser won't send cross-origin without
+//a preflight request
+func checkJsonContentType(contentType string) (err error) {
+	if contentType == "" {
+		return errors.New("Missing content type")
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+
+	if mediaType != "application/json" {
+		return errors.New("Unexpected content type: " + mediaType)
+	}
+
+	return nil
+}
+
+//Responds to a request body that can't be read or parsed
+func badRequest(w http.ResponseWriter, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	} else {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
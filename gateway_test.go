@@ -0,0 +1,180 @@
+package xmlrpc
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func runGateway(gateway *Gateway, body string, t *testing.T) (status int, response string) {
+	server := httptest.NewServer(gateway)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	return resp.StatusCode, string(data)
+}
+
+func TestGatewayCall(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	gateway := NewGateway(NewClient(server.URL), "echo", "fault")
+
+	requests := []string{
+		`{"methodName":"echo","params":[{"string":"main"},{"struct":{"size":{"i8":3000000000}}}]}`,
+		`{"methodName":"fault","params":[]}`}
+	expecteds := []string{
+		`{"result":{"array":[{"string":"main"},{"struct":[{"name":"size","value":{"i8":3000000000}}]}]}}`,
+		`{"fault":{"faultCode":4,"faultString":"Too many parameters"}}`}
+
+	for i, request := range requests {
+		status, actual := runGateway(gateway, request, t)
+
+		if status != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+		}
+		if actual != expecteds[i] {
+			t.Fatalf("Expected %s, got %s", expecteds[i], actual)
+		}
+	}
+}
+
+func TestGatewayUntyped(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	gateway := NewGateway(NewClient(server.URL), "ec*")
+	gateway.Untyped = true
+
+	status, actual := runGateway(gateway, `{"methodName":"echo","params":["main",1,{"done":true,"peers":[null]}]}`, t)
+
+	expected := `{"result":["main",1,{"done":true,"peers":[null]}]}`
+	if status != http.StatusOK || actual != expected {
+		t.Fatalf("Expected %s, got %d %s", expected, status, actual)
+	}
+}
+
+func TestGatewayNotAllowed(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	gateway := NewGateway(NewClient(server.URL), "echo", "system.multicall", "d.*", "f.multicall*")
+
+	requests := []string{
+		`{"methodName":"execute","params":[{"string":"rm"}]}`,
+		`{"methodName":"echoes","params":[]}`,
+		`{"methodName":"system.multicall","params":[{"array":[
+      {"struct":{"methodName":{"string":"echo"},"params":{"array":[]}}},
+      {"struct":{"methodName":{"string":"execute"},"params":{"array":[]}}}]}]}`,
+		`{"methodName":"system.multicall","params":[{"array":[
+      {"struct":{"methodName":{"string":"system.multicall"},"params":{"array":[{"array":[
+        {"struct":{"methodName":{"string":"execute"}}}]}]}}}]}]}`,
+		`{"methodName":"d.multicall2","params":[{"string":""},{"string":"main"},{"string":"execute.throw=rm"}]}`,
+		`{"methodName":"f.multicall","params":[{"string":"hash"},{"string":""},{"string":"execute.throw=rm"}]}`,
+		`{"methodName":"system.multicall","params":[{"array":[
+      {"struct":{"methodName":{"string":"d.multicall.filtered"},"params":{"array":[]}}}]}]}`}
+
+	names := []string{"execute", "echoes", "execute", "execute", "d.multicall2", "f.multicall", "d.multicall.filtered"}
+
+	for i, request := range requests {
+		status, actual := runGateway(gateway, request, t)
+
+		expected := `{"fault":{"faultCode":-32601,"faultString":"Method '` + names[i] + `' not allowed"}}`
+		if status != http.StatusOK || actual != expected {
+			t.Fatalf("Expected %s, got %d %s", expected, status, actual)
+		}
+	}
+
+	invalids := []string{
+		`{"methodName":"system.multicall","params":[{"array":[{"struct":{}}]}]}`,
+		`{"methodName":"system.multicall","params":[{"array":[{"struct":[
+      {"name":"methodName","value":{"string":"echo"}},
+      {"name":"methodName","value":{"string":"execute"}}]}]}]}`,
+		`{"methodName":"system.multicall","params":[{"array":[{"struct":[
+      {"name":"methodName","value":{"string":"system.multicall"}},
+      {"name":"params","value":{"array":[]}},
+      {"name":"params","value":{"array":[{"array":[{"struct":{"methodName":{"string":"execute"}}}]}]}}]}]}]}`,
+		`{"methodName":"system.multicall","params":[{"array":[
+      {"struct":{"methodName":{"string":"echo"},"execute":{"string":"rm"}}}]}]}`}
+
+	for _, request := range invalids {
+		status, actual := runGateway(gateway, request, t)
+		if status != http.StatusOK || !strings.Contains(actual, `"faultCode":-32602`) {
+			t.Fatalf("Expected invalid params fault for %s, got %d %s", request, status, actual)
+		}
+	}
+
+	gateway.Untyped = true
+	status, actual := runGateway(gateway, `{"methodName":"system.multicall","params":[[{"methodName":"echo","methodName":"execute"}]]}`, t)
+	if status != http.StatusOK || !strings.Contains(actual, `"faultCode":-32602`) {
+		t.Fatalf("Expected invalid params fault, got %d %s", status, actual)
+	}
+	gateway.Untyped = false
+
+	status, actual = runGateway(gateway, `{"methodName":"system.multicall","params":[{"array":[
+    {"struct":{"methodName":{"string":"echo"},"params":{"array":[{"i4":1}]}}}]}]}`, t)
+	expected := `{"result":{"array":[{"array":[{"array":[{"int":1}]}]}]}}`
+	if status != http.StatusOK || actual != expected {
+		t.Fatalf("Expected %s, got %d %s", expected, status, actual)
+	}
+}
+
+func TestGatewayHttpStatus(t *testing.T) {
+	server := newTestServer()
+	url := server.URL
+	server.Close()
+
+	status, actual := runGateway(NewGateway(NewClient(url), "echo"), `{"methodName":"echo","params":[]}`, t)
+	if status != http.StatusBadGateway || !strings.Contains(actual, `"faultCode":-32300`) {
+		t.Fatalf("Expected transport fault, got %d %s", status, actual)
+	}
+
+	gateway := httptest.NewServer(NewGateway(NewClient(url), "echo"))
+	defer gateway.Close()
+
+	requests := []struct {
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "text/xml", "{}", http.StatusUnsupportedMediaType},
+		{http.MethodPost, "", `{"methodName":"echo","params":[]}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "text/plain", `{"methodName":"echo","params":[]}`, http.StatusUnsupportedMediaType},
+		{http.MethodPost, "application/json", `{"methodName":"echo","params":[{"int":1}`, http.StatusBadRequest},
+	}
+
+	for _, r := range requests {
+		request, _ := http.NewRequest(r.method, gateway.URL, strings.NewReader(r.body))
+		if r.contentType != "" {
+			request.Header.Set("Content-Type", r.contentType)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != r.status {
+			t.Fatalf("Expected status %d for %s %s, got %d", r.status, r.method, r.body, response.StatusCode)
+		}
+	}
+	limited := NewGateway(NewClient(url), "echo")
+	limited.MaxBytes = 10
+
+	status, _ = runGateway(limited, `{"methodName":"echo","params":[]}`, t)
+	if status != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, status)
+	}
+}
@@ -0,0 +1,123 @@
+package xmlrpc
+
+import (
+	"context"
+	"sort"
+)
+
+//Names of the methods the server provides, from system.listMethods
+func (c *Client) ListMethods(ctx context.Context) (methodNames []string, err error) {
+	value, err := c.Call(ctx, "system.listMethods")
+	if err != nil {
+		return nil, err
+	}
+
+	if err = Unmarshal(*value, &methodNames); err != nil {
+		return nil, err
+	}
+
+	return methodNames, nil
+}
+
+//Signatures of methodName from system.methodSignature, each being the return
+//type followed by the parameter types. Nil if the server doesn't define any.
+func (c *Client) MethodSignature(ctx context.Context, methodName string) (signatures [][]string, err error) {
+	value, err := c.Call(ctx, "system.methodSignature", NewString(methodName))
+	if err != nil {
+		return nil, err
+	}
+
+	if value.Array == nil {
+		return nil, nil
+	}
+
+	if err = Unmarshal(*value, &signatures); err != nil {
+		return nil, err
+	}
+
+	return signatures, nil
+}
+
+//Documentation for methodName from system.methodHelp
+func (c *Client) MethodHelp(ctx context.Context, methodName string) (help string, err error) {
+	value, err := c.Call(ctx, "system.methodHelp", NewString(methodName))
+	if err != nil {
+		return "", err
+	}
+
+	if err = Unmarshal(*value, &help); err != nil {
+		return "", err
+	}
+
+	return help, nil
+}
+
+func (s *Server) registerSystem() {
+	s.methods["system.multicall"] = Method{
+		Handler:    s.multicall,
+		Help:       "Calls each method in an array of structs with methodName and params members, returning an array of results",
+		Signatures: [][]string{{"array", "array"}}}
+	s.methods["system.listMethods"] = Method{
+		Handler:    s.listMethods,
+		Help:       "Returns an array of the methods the server provides",
+		Signatures: [][]string{{"array"}}}
+	s.methods["system.methodSignature"] = Method{
+		Handler:    s.methodSignature,
+		Help:       "Returns an array of signatures for a method, each an array of the return type then the parameter types",
+		Signatures: [][]string{{"array", "string"}}}
+	s.methods["system.methodHelp"] = Method{
+		Handler:    s.methodHelp,
+		Help:       "Returns the documentation for a method",
+		Signatures: [][]string{{"string", "string"}}}
+}
+
+func (s *Server) listMethods(params []Value) (value Value, err error) {
+	s.mutex.RLock()
+	methodNames := make([]string, 0, len(s.methods))
+	for methodName := range s.methods {
+		methodNames = append(methodNames, methodName)
+	}
+	s.mutex.RUnlock()
+
+	sort.Strings(methodNames)
+
+	return Marshal(methodNames)
+}
+
+func (s *Server) methodSignature(params []Value) (value Value, err error) {
+	method, err := s.introspect(params)
+	if err != nil {
+		return Value{}, err
+	}
+
+	if method.Signatures == nil {
+		return NewString("undef"), nil
+	}
+
+	return Marshal(method.Signatures)
+}
+
+func (s *Server) methodHelp(params []Value) (value Value, err error) {
+	method, err := s.introspect(params)
+	if err != nil {
+		return Value{}, err
+	}
+
+	return NewString(method.Help), nil
+}
+
+func (s *Server) introspect(params []Value) (method Method, err error) {
+	if len(params) != 1 || params[0].String == nil {
+		return Method{}, &Fault{Code: FaultInvalidParams, String: "Expecting method name"}
+	}
+
+	s.mutex.RLock()
+	method, ok := s.methods[*params[0].String]
+	s.mutex.RUnlock()
+
+	if !ok {
+		return Method{}, methodNotFound(*params[0].String)
+	}
+
+	return method, nil
+}
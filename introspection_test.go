@@ -0,0 +1,89 @@
+package xmlrpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func newIntrospectionServer() *httptest.Server {
+	server := NewServer()
+
+	server.RegisterMethod("d.name", Method{
+		Handler: func(params []Value) (Value, error) {
+			return NewString("debian.iso"), nil
+		},
+		Help:       "Name of the download",
+		Signatures: [][]string{{"string", "string"}, {"string", "string", "string"}}})
+	server.Register("d.size", func(params []Value) (Value, error) {
+		return NewLong(0), nil
+	})
+
+	return httptest.NewServer(server)
+}
+
+func TestListMethods(t *testing.T) {
+	server := newIntrospectionServer()
+	defer server.Close()
+
+	actual, err := NewClient(server.URL).ListMethods(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"d.name", "d.size", "system.listMethods", "system.methodHelp", "system.methodSignature", "system.multicall"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestMethodSignature(t *testing.T) {
+	server := newIntrospectionServer()
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	actual, err := client.MethodSignature(context.Background(), "d.name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := [][]string{{"string", "string"}, {"string", "string", "string"}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+
+	actual, err = client.MethodSignature(context.Background(), "d.size")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if actual != nil {
+		t.Fatalf("Expected no signatures, got %v", actual)
+	}
+}
+
+func TestMethodHelp(t *testing.T) {
+	server := newIntrospectionServer()
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	actual, err := client.MethodHelp(context.Background(), "d.name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if actual != "Name of the download" {
+		t.Fatalf("Expected %s, got %s", "Name of the download", actual)
+	}
+
+	_, err = client.MethodHelp(context.Background(), "d.missing")
+
+	var fault *Fault
+	if !errors.As(err, &fault) || fault.Code != FaultMethodNotFound {
+		t.Fatalf("Expected method not found fault, got %v", err)
+	}
+}
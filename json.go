@@ -0,0 +1,182 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+type jsonFault struct {
+	Code   int    `json:"faultCode"`
+	String string `json:"faultString"`
+}
+
+//Writes the result of a call as JSON using the type keys read by
+//ParseJsonRequest, such as {"result":{"int":1}}. Struct members are written
+//as [{"name":..,"value":..}] to keep their order. If err is not nil it is
+//written as {"fault":{"faultCode":..,"faultString":..}} instead, using
+//FaultApplicationError unless it is a *Fault.
+func WriteJsonResponse(w io.Writer, value *Value, err error) error {
+	return writeJsonResponse(w, value, err, writeJsonValue)
+}
+
+//Writes the result of a call as plain JSON, such as {"result":1}, with structs
+//as objects, nil as null and dateTime and base64 as strings. Faults are
+//written as by WriteJsonResponse.
+func WriteUntypedJsonResponse(w io.Writer, value *Value, err error) error {
+	return writeJsonResponse(w, value, err, writeUntypedJsonValue)
+}
+
+func writeJsonResponse(w io.Writer, value *Value, err error, writeValue func(*bytes.Buffer, *Value) error) error {
+	var buf bytes.Buffer
+
+	if err != nil {
+		fault := errorFault(err)
+
+		data, err := json.Marshal(struct {
+			Fault jsonFault `json:"fault"`
+		}{jsonFault{Code: fault.Code, String: fault.String}})
+		if err != nil {
+			return err
+		}
+
+		buf.Write(data)
+
+	} else {
+		if value == nil {
+			value = &Value{}
+		}
+
+		buf.WriteString(`{"result":`)
+		if err = writeValue(&buf, value); err != nil {
+			return err
+		}
+		buf.WriteString(`}`)
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeJsonValue(buf *bytes.Buffer, v *Value) (err error) {
+	kind := v.Kind()
+
+	switch kind {
+	case KindInvalid:
+		buf.WriteString(`{}`)
+		return nil
+	case KindArray:
+		buf.WriteString(`{"array":[`)
+		for i := range v.Array {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = writeJsonValue(buf, &v.Array[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(`]}`)
+		return nil
+	case KindStruct:
+		buf.WriteString(`{"struct":[`)
+		for i := range v.Struct {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(`{"name":`)
+			if err = writeJsonData(buf, v.Struct[i].Name); err != nil {
+				return err
+			}
+			buf.WriteString(`,"value":`)
+			if err = writeJsonValue(buf, &v.Struct[i].Value); err != nil {
+				return err
+			}
+			buf.WriteString(`}`)
+		}
+		buf.WriteString(`]}`)
+		return nil
+	}
+
+	buf.WriteString(`{"` + kind.String() + `":`)
+	if err = writeJsonData(buf, jsonData(v)); err != nil {
+		return err
+	}
+	buf.WriteString(`}`)
+
+	return nil
+}
+
+func writeJsonData(buf *bytes.Buffer, data any) (err error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	buf.Write(encoded)
+	return nil
+}
+
+func writeUntypedJsonValue(buf *bytes.Buffer, v *Value) (err error) {
+	switch v.Kind() {
+	case KindInvalid, KindNil:
+		buf.WriteString(`null`)
+	case KindArray:
+		buf.WriteByte('[')
+		for i := range v.Array {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = writeUntypedJsonValue(buf, &v.Array[i]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case KindStruct:
+		buf.WriteByte('{')
+		for i := range v.Struct {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err = writeJsonData(buf, v.Struct[i].Name); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err = writeUntypedJsonValue(buf, &v.Struct[i].Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return writeJsonData(buf, jsonData(v))
+	}
+
+	return nil
+}
+
+//Scalar data of v as the Go value encoding/json should write
+func jsonData(v *Value) (data any) {
+	switch v.Kind() {
+	case KindInt:
+		return *v.Int
+	case KindBoolean:
+		return *v.Boolean
+	case KindString:
+		return *v.String
+	case KindDouble:
+		return *v.Double
+	case KindDateTime:
+		return v.DateTime.Format(iso8601)
+	case KindBase64:
+		return *v.Base64
+	case KindByte:
+		return *v.Byte
+	case KindFloat:
+		return *v.Float
+	case KindLong:
+		return *v.Long
+	case KindShort:
+		return *v.Short
+	}
+
+	return nil
+}
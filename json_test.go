@@ -0,0 +1,222 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJsonResponse(t *testing.T) {
+	values := []Value{
+		NewInt(-49528),
+		NewBoolean(true),
+		NewString("Hello World & \"You\"!"),
+		NewDouble(3.25),
+		NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
+		NewBase64Bytes([]byte("binary\x00data")),
+		NewNil(),
+		NewByte(255),
+		NewFloat(1.5),
+		NewLong(math.MaxInt64),
+		NewShort(-32768),
+		NewArray([]Value{}),
+		NewStruct([]Member{}),
+		NewArray([]Value{
+			NewString("main"),
+			NewStruct([]Member{
+				{Name: "size", Value: NewLong(-4829485744)},
+				{Name: "size", Value: NewArray([]Value{NewNil()})}})})}
+
+	for i := range values {
+		var buf bytes.Buffer
+
+		if err := WriteJsonResponse(&buf, &values[i], nil); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		var response struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &response); err != nil {
+			t.Fatalf("Invalid JSON %s: %s", buf.String(), err)
+		}
+
+		request := `{"methodName":"helloMethod","params":[` + string(response.Result) + `]}`
+
+		_, params, err := ParseJsonRequest(strings.NewReader(request))
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %s", buf.String(), err)
+		}
+
+		if len(params) != 1 {
+			t.Fatalf("Expected 1 param, got %d", len(params))
+		}
+
+		compareValue(&values[i], &params[0], t)
+	}
+}
+
+func TestWriteJsonResponseTypeKeys(t *testing.T) {
+	var buf bytes.Buffer
+
+	value := NewStruct([]Member{{Name: "count", Value: NewInt(2)}})
+	if err := WriteJsonResponse(&buf, &value, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `{"result":{"struct":[{"name":"count","value":{"int":2}}]}}`
+	if buf.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestWriteJsonResponseFault(t *testing.T) {
+	errs := []error{
+		&Fault{Code: 4, String: "Too many parameters"},
+		errors.New("Connection refused")}
+	expecteds := []string{
+		`{"fault":{"faultCode":4,"faultString":"Too many parameters"}}`,
+		`{"fault":{"faultCode":-32500,"faultString":"Connection refused"}}`}
+
+	for i, err := range errs {
+		var buf bytes.Buffer
+
+		if err := WriteJsonResponse(&buf, nil, err); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if buf.String() != expecteds[i] {
+			t.Fatalf("Expected %s, got %s", expecteds[i], buf.String())
+		}
+	}
+}
+
+func TestWriteJsonResponseInvalid(t *testing.T) {
+	var buf bytes.Buffer
+
+	value := NewArray([]Value{NewDouble(math.NaN())})
+	if err := WriteJsonResponse(&buf, &value, nil); err == nil {
+		t.Fatalf("Expected error for NaN")
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("Expected nothing written, got %s", buf.String())
+	}
+}
+
+func TestParseUntypedJsonRequest(t *testing.T) {
+	request := `{"methodName":"d.multicall","params":[
+    "main", 42, -2147483649, 9223372036854775808, 1.5, true, null,
+    "2016-04-07T21:13:58+12:00",
+    [], {},
+    {"name": "a.txt", "tags": ["x", 1], "meta": {"size": 3000000000}}]}`
+
+	expecteds := []Value{
+		NewString("main"),
+		NewInt(42),
+		NewLong(-2147483649),
+		NewDouble(9223372036854775808),
+		NewDouble(1.5),
+		NewBoolean(true),
+		NewNil(),
+		NewString("2016-04-07T21:13:58+12:00"),
+		NewArray([]Value{}),
+		NewStruct([]Member{}),
+		NewStruct([]Member{
+			{Name: "name", Value: NewString("a.txt")},
+			{Name: "tags", Value: NewArray([]Value{NewString("x"), NewInt(1)})},
+			{Name: "meta", Value: NewStruct([]Member{{Name: "size", Value: NewLong(3000000000)}})}})}
+
+	methodName, params, err := ParseUntypedJsonRequest(strings.NewReader(request), JsonRules{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if methodName != "d.multicall" {
+		t.Fatalf("Expected d.multicall, got %s", methodName)
+	}
+
+	if len(params) != len(expecteds) {
+		t.Fatalf("Expected %d params, got %d", len(expecteds), len(params))
+	}
+
+	for i := range expecteds {
+		compareValue(&expecteds[i], &params[i], t)
+	}
+}
+
+func TestParseUntypedJsonRequestRules(t *testing.T) {
+	request := `{"methodName":"d.name","params":[-2147483649, null, "2016-04-07T21:13:58+12:00", "main"]}`
+
+	expecteds := []Value{
+		NewDouble(-2147483649),
+		NewString(""),
+		NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ)),
+		NewString("main")}
+
+	_, params, err := ParseUntypedJsonRequest(strings.NewReader(request), JsonRules{NoExtensions: true, DateTimes: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(params) != len(expecteds) {
+		t.Fatalf("Expected %d params, got %d", len(expecteds), len(params))
+	}
+
+	for i := range expecteds {
+		compareValue(&expecteds[i], &params[i], t)
+	}
+}
+
+func TestParseUntypedJsonRequestInvalid(t *testing.T) {
+	items := []string{`"main"`, `[1e400]`, `[{"name"}]`, `[1, `}
+
+	for _, item := range items {
+		request := `{"methodName":"d.name","params":` + item + `}`
+
+		if _, _, err := ParseUntypedJsonRequest(strings.NewReader(request), JsonRules{}); err == nil {
+			t.Fatalf("Expected error for %s", item)
+		}
+	}
+}
+
+func TestWriteUntypedJsonResponse(t *testing.T) {
+	var buf bytes.Buffer
+
+	value := NewStruct([]Member{
+		{Name: "name", Value: NewString("a.txt")},
+		{Name: "size", Value: NewLong(3000000000)},
+		{Name: "ratio", Value: NewFloat(0.5)},
+		{Name: "done", Value: NewBoolean(false)},
+		{Name: "peers", Value: NewArray([]Value{NewInt(1), NewShort(2), NewByte(3)})},
+		{Name: "added", Value: NewDateTime(time.Date(2016, 4, 7, 21, 13, 58, 0, NZ))},
+		{Name: "data", Value: NewBase64("AAE=")},
+		{Name: "label", Value: NewNil()}})
+
+	if err := WriteUntypedJsonResponse(&buf, &value, nil); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `{"result":{"name":"a.txt","size":3000000000,"ratio":0.5,"done":false,` +
+		`"peers":[1,2,3],"added":"2016-04-07T21:13:58+1200","data":"AAE=","label":null}}`
+	if buf.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buf.String())
+	}
+}
+
+func TestWriteUntypedJsonResponseFault(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := WriteUntypedJsonResponse(&buf, nil, &Fault{Code: 4, String: "Too many parameters"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := `{"fault":{"faultCode":4,"faultString":"Too many parameters"}}`
+	if buf.String() != expected {
+		t.Fatalf("Expected %s, got %s", expected, buf.String())
+	}
+}
@@ -0,0 +1,189 @@
+package xmlrpc
+
+import (
+	"errors"
+	"time"
+)
+
+//Largest magnitude integer every smaller one converts to float64 exactly, 2^53
+const maxExactFloat = 1 << 53
+
+//Type of data held by a Value
+type Kind int
+
+const (
+	KindInvalid Kind = iota
+	KindInt
+	KindBoolean
+	KindString
+	KindDouble
+	KindDateTime
+	KindBase64
+	KindArray
+	KindStruct
+	KindNil
+	KindByte
+	KindFloat
+	KindLong
+	KindShort
+)
+
+var kindNames = []string{
+	KindInvalid:  "invalid",
+	KindInt:      "int",
+	KindBoolean:  "boolean",
+	KindString:   "string",
+	KindDouble:   "double",
+	KindDateTime: "dateTime.iso8601",
+	KindBase64:   "base64",
+	KindArray:    "array",
+	KindStruct:   "struct",
+	KindNil:      "nil",
+	KindByte:     "i1",
+	KindFloat:    "float",
+	KindLong:     "i8",
+	KindShort:    "i2",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "invalid"
+	}
+	return kindNames[k]
+}
+
+func (v *Value) Kind() Kind {
+	if v.Int != nil {
+		return KindInt
+	} else if v.Boolean != nil {
+		return KindBoolean
+	} else if v.String != nil {
+		return KindString
+	} else if v.Double != nil {
+		return KindDouble
+	} else if v.DateTime != nil {
+		return KindDateTime
+	} else if v.Base64 != nil {
+		return KindBase64
+	} else if v.Nil != nil {
+		return KindNil
+	} else if v.Byte != nil {
+		return KindByte
+	} else if v.Float != nil {
+		return KindFloat
+	} else if v.Long != nil {
+		return KindLong
+	} else if v.Short != nil {
+		return KindShort
+	} else if v.Array != nil {
+		return KindArray
+	} else if v.Struct != nil {
+		return KindStruct
+	}
+
+	return KindInvalid
+}
+
+//Value of any integer kind (i1, i2, i4 or i8)
+func (v *Value) AsInt64() (i int64, err error) {
+	switch v.Kind() {
+	case KindInt:
+		return int64(*v.Int), nil
+	case KindLong:
+		return *v.Long, nil
+	case KindShort:
+		return int64(*v.Short), nil
+	case KindByte:
+		return int64(*v.Byte), nil
+	}
+
+	return 0, v.kindError("int64")
+}
+
+//Value of a double or float, or an integer kind that converts exactly
+func (v *Value) AsFloat64() (f float64, err error) {
+	switch v.Kind() {
+	case KindDouble:
+		return *v.Double, nil
+	case KindFloat:
+		return float64(*v.Float), nil
+	case KindInt, KindShort, KindByte:
+		i, _ := v.AsInt64()
+		return float64(i), nil
+	case KindLong:
+		if *v.Long >= -maxExactFloat && *v.Long <= maxExactFloat {
+			return float64(*v.Long), nil
+		}
+	}
+
+	return 0, v.kindError("float64")
+}
+
+func (v *Value) AsBool() (b bool, err error) {
+	if v.Boolean == nil {
+		return false, v.kindError("bool")
+	}
+	return *v.Boolean, nil
+}
+
+func (v *Value) AsString() (str string, err error) {
+	if v.String == nil {
+		return "", v.kindError("string")
+	}
+	return *v.String, nil
+}
+
+func (v *Value) AsTime() (t time.Time, err error) {
+	if v.DateTime == nil {
+		return time.Time{}, v.kindError("time")
+	}
+	return *v.DateTime, nil
+}
+
+//Decoded base64 data
+func (v *Value) AsBytes() (data []byte, err error) {
+	if v.Base64 == nil {
+		return nil, v.kindError("bytes")
+	}
+	return v.Bytes()
+}
+
+func (v *Value) AsSlice() (values []Value, err error) {
+	if v.Kind() != KindArray {
+		return nil, v.kindError("slice")
+	}
+	return v.Array, nil
+}
+
+//Struct members by name, the last member wins if a name is repeated
+func (v *Value) AsMap() (members map[string]Value, err error) {
+	if v.Kind() != KindStruct {
+		return nil, v.kindError("map")
+	}
+
+	members = make(map[string]Value, len(v.Struct))
+	for _, mem := range v.Struct {
+		members[mem.Name] = mem.Value
+	}
+
+	return members, nil
+}
+
+//Value of the first struct member called memberName
+func (v *Value) Get(memberName string) (value *Value, err error) {
+	if v.Kind() != KindStruct {
+		return nil, v.kindError("struct")
+	}
+
+	for i := range v.Struct {
+		if v.Struct[i].Name == memberName {
+			return &v.Struct[i].Value, nil
+		}
+	}
+
+	return nil, errors.New("No member named " + memberName)
+}
+
+func (v *Value) kindError(target string) (err error) {
+	return errors.New("Cannot convert " + v.Kind().String() + " to " + target)
+}
@@ -0,0 +1,163 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestKind(t *testing.T) {
+	values := []Value{
+		{},
+		NewInt(1),
+		NewBoolean(true),
+		NewString("s"),
+		NewDouble(1.5),
+		NewDateTime(time.Now()),
+		NewBase64(""),
+		NewArray([]Value{}),
+		NewStruct([]Member{}),
+		NewNil(),
+		NewByte(1),
+		NewFloat(1.5),
+		NewLong(1),
+		NewShort(1)}
+	expecteds := []Kind{KindInvalid, KindInt, KindBoolean, KindString, KindDouble, KindDateTime, KindBase64,
+		KindArray, KindStruct, KindNil, KindByte, KindFloat, KindLong, KindShort}
+
+	for i, value := range values {
+		if actual := value.Kind(); actual != expecteds[i] {
+			t.Fatalf("Expected %s, got %s", expecteds[i], actual)
+		}
+	}
+}
+
+func TestAsInt64(t *testing.T) {
+	values := []Value{NewInt(-2147483648), NewLong(9223372036854775807), NewShort(-32768), NewByte(255)}
+	expecteds := []int64{-2147483648, 9223372036854775807, -32768, 255}
+
+	for i, value := range values {
+		actual, err := value.AsInt64()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if actual != expecteds[i] {
+			t.Fatalf("Expected %d, got %d", expecteds[i], actual)
+		}
+	}
+
+	for _, value := range []Value{NewString("1"), NewDouble(1), NewNil()} {
+		if _, err := value.AsInt64(); err == nil {
+			t.Fatalf("Expected error converting %s", printValue(&value))
+		}
+	}
+}
+
+func TestAsFloat64(t *testing.T) {
+	values := []Value{NewDouble(1.25), NewFloat(-0.5), NewInt(3), NewShort(-4), NewByte(5),
+		NewLong(6), NewLong(9007199254740992), NewLong(-9007199254740992)}
+	expecteds := []float64{1.25, -0.5, 3, -4, 5, 6, 9007199254740992, -9007199254740992}
+
+	for i, value := range values {
+		actual, err := value.AsFloat64()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if actual != expecteds[i] {
+			t.Fatalf("Expected %f, got %f", expecteds[i], actual)
+		}
+	}
+
+	for _, value := range []Value{NewLong(9007199254740993), NewLong(-9007199254740993)} {
+		if _, err := value.AsFloat64(); err == nil {
+			t.Fatalf("Expected error converting %s", printValue(&value))
+		}
+	}
+}
+
+func TestAsScalars(t *testing.T) {
+	now := time.Date(2016, 3, 21, 11, 32, 10, 0, NZ)
+
+	str := NewString("main")
+	if actual, err := str.AsString(); err != nil || actual != "main" {
+		t.Fatalf("Expected main, got %s (%v)", actual, err)
+	}
+
+	b := NewBoolean(true)
+	if actual, err := b.AsBool(); err != nil || !actual {
+		t.Fatalf("Expected true, got %v (%v)", actual, err)
+	}
+
+	dateTime := NewDateTime(now)
+	if actual, err := dateTime.AsTime(); err != nil || !actual.Equal(now) {
+		t.Fatalf("Expected %s, got %s (%v)", now, actual, err)
+	}
+
+	data := NewBase64Bytes([]byte("data"))
+	if actual, err := data.AsBytes(); err != nil || !bytes.Equal(actual, []byte("data")) {
+		t.Fatalf("Expected data, got %s (%v)", actual, err)
+	}
+
+	if _, err := str.AsBool(); err == nil {
+		t.Fatalf("Expected error converting string to bool")
+	}
+	if _, err := b.AsString(); err == nil {
+		t.Fatalf("Expected error converting boolean to string")
+	}
+	if _, err := str.AsTime(); err == nil {
+		t.Fatalf("Expected error converting string to time")
+	}
+	if _, err := str.AsBytes(); err == nil {
+		t.Fatalf("Expected error converting string to bytes")
+	}
+}
+
+func TestAsContainers(t *testing.T) {
+	_, values := structData()
+	value := values[0]
+
+	members, err := value.AsMap()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(members) != 4 {
+		t.Fatalf("Expected 4 members, got %d", len(members))
+	}
+
+	array, err := value.Get("4th Array")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	items, err := array.AsSlice()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(items) != 3 {
+		t.Fatalf("Expected 3 items, got %d", len(items))
+	}
+
+	i, err := items[2].Get("4th #3 - #2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if actual, err := i.AsInt64(); err != nil || actual != 2000 {
+		t.Fatalf("Expected 2000, got %d (%v)", actual, err)
+	}
+
+	if _, err := value.Get("missing"); err == nil {
+		t.Fatalf("Expected error for missing member")
+	}
+	if _, err := array.Get("1st item"); err == nil {
+		t.Fatalf("Expected error for array member")
+	}
+	if _, err := value.AsSlice(); err == nil {
+		t.Fatalf("Expected error converting struct to slice")
+	}
+	if _, err := array.AsMap(); err == nil {
+		t.Fatalf("Expected error converting array to map")
+	}
+}
@@ -0,0 +1,391 @@
+package xmlrpc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	valueType = reflect.TypeOf(Value{})
+	timeType  = reflect.TypeOf(time.Time{})
+)
+
+//Converts a Go value to a Value.
+//
+//Structs become <struct> using the field name, or the name from an
+//`xmlrpc:"name,omitempty"` tag. Maps with string keys also become <struct>,
+//slices and arrays become <array>, []byte becomes <base64>, time.Time becomes
+//<dateTime.iso8601> and nil pointers, interfaces, maps and slices become <nil>.
+//
+//int8 and int16 become <i2>, uint8 <i1>, float32 <float> and int64 <i8>. Other
+//integers become <i4> if they fit, otherwise <i8>.
+func Marshal(v any) (value Value, err error) {
+	if v == nil {
+		return NewNil(), nil
+	}
+
+	return marshalValue(reflect.ValueOf(v))
+}
+
+func marshalValue(rv reflect.Value) (value Value, err error) {
+	switch rv.Type() {
+	case valueType:
+		return rv.Interface().(Value), nil
+	case timeType:
+		return NewDateTime(rv.Interface().(time.Time)), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return NewBoolean(rv.Bool()), nil
+	case reflect.Int8, reflect.Int16:
+		return NewShort(int16(rv.Int())), nil
+	case reflect.Int32:
+		return NewInt(int32(rv.Int())), nil
+	case reflect.Int64:
+		return NewLong(rv.Int()), nil
+	case reflect.Int:
+		return marshalInt(rv.Int()), nil
+	case reflect.Uint8:
+		return NewByte(byte(rv.Uint())), nil
+	case reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			return Value{}, fmt.Errorf("Cannot marshal %d, too large for i8", u)
+		}
+		return marshalInt(int64(u)), nil
+	case reflect.Float32:
+		return NewFloat(float32(rv.Float())), nil
+	case reflect.Float64:
+		return NewDouble(rv.Float()), nil
+	case reflect.String:
+		return NewString(rv.String()), nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return NewNil(), nil
+		}
+		return marshalValue(rv.Elem())
+	case reflect.Slice:
+		if rv.IsNil() {
+			return NewNil(), nil
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return NewBase64Bytes(rv.Bytes()), nil
+		}
+		return marshalArray(rv)
+	case reflect.Array:
+		return marshalArray(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return NewNil(), nil
+		}
+		return marshalMap(rv)
+	case reflect.Struct:
+		return marshalStruct(rv)
+	}
+
+	return Value{}, errors.New("Cannot marshal " + rv.Type().String())
+}
+
+func marshalInt(i int64) (value Value) {
+	if i < math.MinInt32 || i > math.MaxInt32 {
+		return NewLong(i)
+	}
+	return NewInt(int32(i))
+}
+
+func marshalArray(rv reflect.Value) (value Value, err error) {
+	values := make([]Value, rv.Len())
+
+	for i := range values {
+		if values[i], err = marshalValue(rv.Index(i)); err != nil {
+			return Value{}, err
+		}
+	}
+
+	return NewArray(values), nil
+}
+
+func marshalMap(rv reflect.Value) (value Value, err error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return Value{}, errors.New("Cannot marshal " + rv.Type().String() + ", keys must be strings")
+	}
+
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	members := make([]Member, len(keys))
+
+	for i, key := range keys {
+		members[i].Name = key.String()
+		if members[i].Value, err = marshalValue(rv.MapIndex(key)); err != nil {
+			return Value{}, err
+		}
+	}
+
+	return NewStruct(members), nil
+}
+
+func marshalStruct(rv reflect.Value) (value Value, err error) {
+	members := make([]Member, 0)
+
+	for _, field := range structFields(rv.Type()) {
+		fv := rv.FieldByIndex(field.index)
+
+		if field.omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		member := Member{Name: field.name}
+		if member.Value, err = marshalValue(fv); err != nil {
+			return Value{}, err
+		}
+
+		members = append(members, member)
+	}
+
+	return NewStruct(members), nil
+}
+
+//Converts a Value to the Go value pointed to by v, following the same rules
+//as Marshal. Integer types are widened or narrowed as needed, failing if the
+//number doesn't fit. Struct members without a matching field are ignored.
+func Unmarshal(value Value, v any) (err error) {
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("Unmarshal needs a non-nil pointer")
+	}
+
+	return unmarshalValue(&value, rv.Elem())
+}
+
+func unmarshalValue(value *Value, rv reflect.Value) (err error) {
+	if rv.Type() == valueType {
+		rv.Set(reflect.ValueOf(*value))
+		return nil
+	}
+
+	if value.Nil != nil {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	if rv.Type() == timeType {
+		if value.DateTime == nil {
+			return unmarshalError(value, rv)
+		}
+		rv.Set(reflect.ValueOf(*value.DateTime))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if value.Boolean == nil {
+			return unmarshalError(value, rv)
+		}
+		rv.SetBool(*value.Boolean)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := value.AsInt64()
+		if err != nil || rv.OverflowInt(i) {
+			return unmarshalError(value, rv)
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, err := value.AsInt64()
+		if err != nil || i < 0 || rv.OverflowUint(uint64(i)) {
+			return unmarshalError(value, rv)
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, err := value.AsFloat64()
+		if err != nil || rv.OverflowFloat(f) {
+			return unmarshalError(value, rv)
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		if value.String == nil {
+			return unmarshalError(value, rv)
+		}
+		rv.SetString(*value.String)
+	case reflect.Pointer:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return unmarshalValue(value, rv.Elem())
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return unmarshalError(value, rv)
+		}
+		var i any
+		if i, err = unmarshalInterface(value); err != nil {
+			return err
+		}
+		if i == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+		} else {
+			rv.Set(reflect.ValueOf(i))
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && value.Base64 != nil {
+			b, err := value.Bytes()
+			if err != nil {
+				return err
+			}
+			rv.SetBytes(b)
+			return nil
+		}
+		if value.Array == nil {
+			return unmarshalError(value, rv)
+		}
+		rv.Set(reflect.MakeSlice(rv.Type(), len(value.Array), len(value.Array)))
+		for i := range value.Array {
+			if err = unmarshalValue(&value.Array[i], rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Array:
+		if value.Array == nil || len(value.Array) > rv.Len() {
+			return unmarshalError(value, rv)
+		}
+		for i := range value.Array {
+			if err = unmarshalValue(&value.Array[i], rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if value.Struct == nil || rv.Type().Key().Kind() != reflect.String {
+			return unmarshalError(value, rv)
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMapWithSize(rv.Type(), len(value.Struct)))
+		}
+		for i := range value.Struct {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err = unmarshalValue(&value.Struct[i].Value, elem); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(value.Struct[i].Name).Convert(rv.Type().Key()), elem)
+		}
+	case reflect.Struct:
+		if value.Struct == nil {
+			return unmarshalError(value, rv)
+		}
+		fields := structFields(rv.Type())
+		for i := range value.Struct {
+			mem := &value.Struct[i]
+			for _, field := range fields {
+				if field.name == mem.Name {
+					if err = unmarshalValue(&mem.Value, rv.FieldByIndex(field.index)); err != nil {
+						return err
+					}
+					break
+				}
+			}
+		}
+	default:
+		return unmarshalError(value, rv)
+	}
+
+	return nil
+}
+
+//Converts to the natural Go type for an empty interface
+func unmarshalInterface(value *Value) (i any, err error) {
+	if value.Int != nil {
+		return int(*value.Int), nil
+	} else if value.Boolean != nil {
+		return *value.Boolean, nil
+	} else if value.String != nil {
+		return *value.String, nil
+	} else if value.Double != nil {
+		return *value.Double, nil
+	} else if value.DateTime != nil {
+		return *value.DateTime, nil
+	} else if value.Base64 != nil {
+		return value.Bytes()
+	} else if value.Array != nil {
+		values := make([]any, len(value.Array))
+		for j := range value.Array {
+			if values[j], err = unmarshalInterface(&value.Array[j]); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	} else if value.Struct != nil {
+		members := make(map[string]any, len(value.Struct))
+		for _, mem := range value.Struct {
+			if members[mem.Name], err = unmarshalInterface(&mem.Value); err != nil {
+				return nil, err
+			}
+		}
+		return members, nil
+	} else if value.Nil != nil {
+		return nil, nil
+	} else if value.Byte != nil {
+		return *value.Byte, nil
+	} else if value.Float != nil {
+		return *value.Float, nil
+	} else if value.Long != nil {
+		return *value.Long, nil
+	} else if value.Short != nil {
+		return *value.Short, nil
+	}
+
+	return nil, nil
+}
+
+func unmarshalError(value *Value, rv reflect.Value) (err error) {
+	return errors.New("Cannot unmarshal " + value.Kind().String() + " into " + rv.Type().String())
+}
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+//Exported fields of a struct type, including those of embedded structs
+func structFields(t reflect.Type) (fields []structField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("xmlrpc")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for _, embedded := range structFields(field.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, structField{
+			name:      name,
+			index:     []int{i},
+			omitEmpty: options == "omitempty",
+		})
+	}
+
+	return fields
+}
@@ -0,0 +1,176 @@
+package xmlrpc
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type marshalTorrent struct {
+	Hash     string            `xmlrpc:"hash"`
+	Name     string            `xmlrpc:"name,omitempty"`
+	Size     int64             `xmlrpc:"size"`
+	Files    int               `xmlrpc:"files"`
+	Priority int16             `xmlrpc:"priority"`
+	Flags    uint8             `xmlrpc:"flags"`
+	Ratio    float32           `xmlrpc:"ratio"`
+	Rate     float64           `xmlrpc:"rate"`
+	Active   bool              `xmlrpc:"active"`
+	Created  time.Time         `xmlrpc:"created"`
+	Info     []byte            `xmlrpc:"info"`
+	Tags     []string          `xmlrpc:"tags"`
+	Custom   map[string]string `xmlrpc:"custom"`
+	Parent   *marshalTorrent   `xmlrpc:"parent"`
+	Ignored  string            `xmlrpc:"-"`
+	Untagged string
+	hidden   string
+}
+
+func marshalTorrentData() (torrent marshalTorrent, value Value) {
+	created := time.Date(2016, 3, 21, 11, 32, 10, 0, NZ)
+
+	return marshalTorrent{
+			Hash:     "ABCDEF",
+			Size:     5368709120,
+			Files:    3,
+			Priority: -1,
+			Flags:    255,
+			Ratio:    1.5,
+			Rate:     1024.25,
+			Active:   true,
+			Created:  created,
+			Info:     []byte("hello"),
+			Tags:     []string{"linux", "iso"},
+			Custom:   map[string]string{"b": "2", "a": "1"},
+			Untagged: "yes"},
+		NewStruct([]Member{
+			{Name: "hash", Value: NewString("ABCDEF")},
+			{Name: "size", Value: NewLong(5368709120)},
+			{Name: "files", Value: NewInt(3)},
+			{Name: "priority", Value: NewShort(-1)},
+			{Name: "flags", Value: NewByte(255)},
+			{Name: "ratio", Value: NewFloat(1.5)},
+			{Name: "rate", Value: NewDouble(1024.25)},
+			{Name: "active", Value: NewBoolean(true)},
+			{Name: "created", Value: NewDateTime(created)},
+			{Name: "info", Value: NewBase64("aGVsbG8=")},
+			{Name: "tags", Value: NewArray([]Value{NewString("linux"), NewString("iso")})},
+			{Name: "custom", Value: NewStruct([]Member{
+				{Name: "a", Value: NewString("1")},
+				{Name: "b", Value: NewString("2")}})},
+			{Name: "parent", Value: NewNil()},
+			{Name: "Untagged", Value: NewString("yes")}})
+}
+
+func TestMarshal(t *testing.T) {
+	torrent, expected := marshalTorrentData()
+
+	actual, err := Marshal(torrent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	t.Logf("Expected: %s\n", printValue(&expected))
+	t.Logf("Actual: %s\n", printValue(&actual))
+
+	compareValue(&expected, &actual, t)
+}
+
+func TestMarshalScalars(t *testing.T) {
+	var nilPtr *int
+
+	inputs := []any{nil, nilPtr, 7, 3000000000, int32(-5), uint16(65535), "text", NewShort(4)}
+	expecteds := []Value{NewNil(), NewNil(), NewInt(7), NewLong(3000000000), NewInt(-5), NewInt(65535), NewString("text"), NewShort(4)}
+
+	for i, input := range inputs {
+		actual, err := Marshal(input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		compareValue(&expecteds[i], &actual, t)
+	}
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	if _, err := Marshal(map[int]string{1: "one"}); err == nil {
+		t.Fatalf("Expected error for non-string map keys")
+	}
+	if _, err := Marshal(make(chan int)); err == nil {
+		t.Fatalf("Expected error for channel")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	expected, value := marshalTorrentData()
+	expected.Untagged = "yes"
+
+	var actual marshalTorrent
+	if err := Unmarshal(value, &actual); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if !actual.Created.Equal(expected.Created) {
+		t.Fatalf("Expected %s, got %s", expected.Created, actual.Created)
+	}
+	actual.Created = expected.Created
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestUnmarshalWiden(t *testing.T) {
+	var i64 int64
+	if err := Unmarshal(NewShort(-3), &i64); err != nil || i64 != -3 {
+		t.Fatalf("Expected -3, got %d (%v)", i64, err)
+	}
+
+	var f float64
+	if err := Unmarshal(NewInt(2), &f); err != nil || f != 2 {
+		t.Fatalf("Expected 2, got %f (%v)", f, err)
+	}
+	if err := Unmarshal(NewLong(5), &f); err != nil || f != 5 {
+		t.Fatalf("Expected 5, got %f (%v)", f, err)
+	}
+
+	var i8 int8
+	if err := Unmarshal(NewInt(300), &i8); err == nil {
+		t.Fatalf("Expected overflow error, got %d", i8)
+	}
+
+	var u uint
+	if err := Unmarshal(NewLong(-1), &u); err == nil {
+		t.Fatalf("Expected overflow error, got %d", u)
+	}
+
+	var s string
+	if err := Unmarshal(NewInt(1), &s); err == nil {
+		t.Fatalf("Expected type error, got %s", s)
+	}
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	value := NewArray([]Value{
+		NewInt(1),
+		NewString("two"),
+		NewNil(),
+		NewStruct([]Member{{Name: "four", Value: NewLong(4)}})})
+
+	var actual any
+	if err := Unmarshal(value, &actual); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []any{1, "two", nil, map[string]any{"four": int64(4)}}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("Expected %#v, got %#v", expected, actual)
+	}
+}
+
+func TestUnmarshalNotPointer(t *testing.T) {
+	var i int
+	if err := Unmarshal(NewInt(1), i); err == nil {
+		t.Fatalf("Expected error for non-pointer")
+	}
+}
@@ -0,0 +1,116 @@
+package xmlrpc
+
+import (
+	"context"
+	"errors"
+)
+
+//Calls to send together as a single system.multicall
+type Batch struct {
+	calls []Value
+}
+
+func NewBatch() *Batch {
+	return &Batch{calls: make([]Value, 0)}
+}
+
+//Adds a call of methodName to the batch
+func (b *Batch) Add(methodName string, params ...Value) {
+	b.calls = append(b.calls, NewStruct([]Member{
+		{Name: "methodName", Value: NewString(methodName)},
+		{Name: "params", Value: NewArray(append(make([]Value, 0, len(params)), params...))}}))
+}
+
+func (b *Batch) Len() int {
+	return len(b.calls)
+}
+
+//Result of one call in a batch, Err is a *Fault if the call failed
+type BatchResult struct {
+	Value *Value
+	Err   error
+}
+
+//Sends the batch as a single system.multicall, returning a result for each
+//call in the order they were added. The error is only set if the multicall
+//itself failed.
+func (c *Client) CallBatch(ctx context.Context, batch *Batch) (results []BatchResult, err error) {
+	value, err := c.Call(ctx, "system.multicall", NewArray(append(make([]Value, 0, len(batch.calls)), batch.calls...)))
+	if err != nil {
+		return nil, err
+	}
+
+	if value.Array == nil || len(value.Array) != len(batch.calls) {
+		return nil, errors.New("Expecting array of multicall results")
+	}
+
+	results = make([]BatchResult, len(value.Array))
+
+	for i := range value.Array {
+		if results[i], err = newBatchResult(&value.Array[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+func newBatchResult(value *Value) (result BatchResult, err error) {
+	if len(value.Array) == 1 {
+		return BatchResult{Value: &value.Array[0]}, nil
+	}
+
+	if value.Struct != nil {
+		fault, err := newFault(value)
+		if err != nil {
+			return BatchResult{}, err
+		}
+		return BatchResult{Err: fault}, nil
+	}
+
+	return BatchResult{}, errors.New("Unexpected multicall result")
+}
+
+//Handles system.multicall, calling each method and collecting the results
+func (s *Server) multicall(params []Value) (value Value, err error) {
+	if len(params) != 1 || params[0].Array == nil {
+		return Value{}, &Fault{Code: FaultInvalidParams, String: "Expecting array of calls"}
+	}
+
+	results := make([]Value, len(params[0].Array))
+
+	for i, call := range params[0].Array {
+		value, err := s.multicallOne(&call)
+		if err != nil {
+			results[i] = faultValue(errorFault(err))
+		} else {
+			results[i] = NewArray([]Value{value})
+		}
+	}
+
+	return NewArray(results), nil
+}
+
+func (s *Server) multicallOne(call *Value) (value Value, err error) {
+	var methodName *string
+	var params []Value
+
+	for _, mem := range call.Struct {
+		switch mem.Name {
+		case "methodName":
+			methodName = mem.Value.String
+		case "params":
+			params = mem.Value.Array
+		}
+	}
+
+	if methodName == nil {
+		return Value{}, &Fault{Code: FaultInvalidRequest, String: "Expecting methodName"}
+	}
+
+	if *methodName == "system.multicall" {
+		return Value{}, &Fault{Code: FaultInvalidRequest, String: "Recursive system.multicall forbidden"}
+	}
+
+	return s.call(*methodName, params)
+}
@@ -0,0 +1,75 @@
+package xmlrpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCallBatch(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	batch := NewBatch()
+	batch.Add("echo", NewString("main"))
+	batch.Add("missing")
+	batch.Add("fault", NewInt(1), NewInt(2))
+	batch.Add("echo")
+	batch.Add("system.multicall", NewArray([]Value{}))
+
+	if batch.Len() != 5 {
+		t.Fatalf("Expected 5 calls, got %d", batch.Len())
+	}
+
+	results, err := NewClient(server.URL).CallBatch(context.Background(), batch)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(results) != batch.Len() {
+		t.Fatalf("Expected %d results, got %d", batch.Len(), len(results))
+	}
+
+	expecteds := []Value{NewArray([]Value{NewString("main")}), NewArray([]Value{})}
+	for i, index := range []int{0, 3} {
+		if results[index].Err != nil {
+			t.Fatalf("Unexpected error: %s", results[index].Err)
+		}
+		compareValue(&expecteds[i], results[index].Value, t)
+	}
+
+	faults := []Fault{
+		{Code: FaultMethodNotFound, String: "Method 'missing' not defined"},
+		{Code: 4, String: "Too many parameters"},
+		{Code: FaultInvalidRequest, String: "Recursive system.multicall forbidden"}}
+	for i, index := range []int{1, 2, 4} {
+		var fault *Fault
+		if !errors.As(results[index].Err, &fault) {
+			t.Fatalf("Expected fault, got %v", results[index].Err)
+		}
+		if *fault != faults[i] {
+			t.Fatalf("Expected %#v, got %#v", faults[i], *fault)
+		}
+		if results[index].Value != nil {
+			t.Fatalf("Expected no value, got %s", printValue(results[index].Value))
+		}
+	}
+}
+
+func TestCallBatchEmpty(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	results, err := NewClient(server.URL).CallBatch(context.Background(), NewBatch())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(results) != 0 {
+		t.Fatalf("Expected no results, got %d", len(results))
+	}
+}
+
+func TestServerMulticallInvalid(t *testing.T) {
+	runServerFault("system.multicall", Fault{Code: FaultInvalidParams}, t)
+}
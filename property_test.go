@@ -0,0 +1,214 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+)
+
+//Characters that must survive both XML and JSON, including markup, quotes,
+//white space and multi-byte runes
+var randomRunes = []rune("abcXYZ019 _-.<>&'\"\t\n\r;:/\\=]]>é中文💾  ")
+
+//Generates random Value trees of every kind
+type valueGenerator struct {
+	rand     *rand.Rand
+	maxDepth int
+}
+
+func newValueGenerator(seed int64) *valueGenerator {
+	return &valueGenerator{rand: rand.New(rand.NewSource(seed)), maxDepth: 6}
+}
+
+func (g *valueGenerator) value(depth int) Value {
+	kind := Kind(1 + g.rand.Intn(int(KindShort)))
+	if depth < g.maxDepth && g.rand.Intn(3) == 0 {
+		kind = []Kind{KindArray, KindStruct}[g.rand.Intn(2)]
+	} else if depth >= g.maxDepth && (kind == KindArray || kind == KindStruct) {
+		kind = KindString
+	}
+
+	switch kind {
+	case KindInt:
+		return NewInt(int32(g.rand.Uint32()))
+	case KindBoolean:
+		return NewBoolean(g.rand.Intn(2) == 1)
+	case KindString:
+		return NewString(g.string())
+	case KindDouble:
+		return NewDouble(g.float64())
+	case KindDateTime:
+		return NewDateTime(g.time())
+	case KindBase64:
+		data := make([]byte, g.rand.Intn(40))
+		g.rand.Read(data)
+		return NewBase64Bytes(data)
+	case KindArray:
+		values := make([]Value, g.rand.Intn(5))
+		for i := range values {
+			values[i] = g.value(depth + 1)
+		}
+		return NewArray(values)
+	case KindStruct:
+		members := make([]Member, g.rand.Intn(5))
+		for i := range members {
+			members[i] = Member{Name: g.string(), Value: g.value(depth + 1)}
+		}
+		return NewStruct(members)
+	case KindNil:
+		return NewNil()
+	case KindByte:
+		return NewByte(byte(g.rand.Intn(256)))
+	case KindFloat:
+		return NewFloat(g.float32())
+	case KindLong:
+		return NewLong(int64(g.rand.Uint64()))
+	case KindShort:
+		return NewShort(int16(g.rand.Uint32()))
+	}
+
+	panic("Unhandled kind " + kind.String())
+}
+
+func (g *valueGenerator) string() string {
+	runes := make([]rune, g.rand.Intn(20))
+	for i := range runes {
+		runes[i] = randomRunes[g.rand.Intn(len(randomRunes))]
+	}
+	return string(runes)
+}
+
+func (g *valueGenerator) float64() float64 {
+	switch g.rand.Intn(4) {
+	case 0:
+		return float64(g.rand.Intn(2000) - 1000)
+	case 1:
+		return g.rand.NormFloat64() * math.Pow(10, float64(g.rand.Intn(40)-20))
+	case 2:
+		return math.Float64frombits(g.rand.Uint64()&^(0x7ff<<52) | uint64(g.rand.Intn(0x7fe)+1)<<52)
+	}
+	return 0
+}
+
+func (g *valueGenerator) float32() float32 {
+	f := float32(g.float64())
+	if math.IsInf(float64(f), 0) {
+		return float32(math.Copysign(math.MaxFloat32, float64(f)))
+	}
+	return f
+}
+
+func (g *valueGenerator) time() time.Time {
+	offset := (g.rand.Intn(27*4) - 12*4) * 15 * 60
+	seconds := g.rand.Int63n(4102444800) //until 2100
+
+	return time.Unix(seconds, 0).In(time.FixedZone("", offset))
+}
+
+func runValueProperty(property func(value Value, t *testing.T), t *testing.T) {
+	for seed := int64(0); seed < 500; seed++ {
+		value := newValueGenerator(seed).value(0)
+
+		property(value, t)
+
+		if t.Failed() {
+			t.Fatalf("Failed for seed %d: %s", seed, printValue(&value))
+		}
+	}
+}
+
+func TestXmlRoundTripProperty(t *testing.T) {
+	runValueProperty(func(value Value, t *testing.T) {
+		actual, err := ParseResponse(bytes.NewBuffer(CreateResponse(value)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		compareValue(&value, actual, t)
+	}, t)
+}
+
+func TestXmlRequestRoundTripProperty(t *testing.T) {
+	runValueProperty(func(value Value, t *testing.T) {
+		params := []Value{value, NewString("main")}
+
+		_, actuals, err := ParseRequest(bytes.NewReader(CreateRequest("d.multicall", params)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(actuals) != len(params) {
+			t.Fatalf("Expected %d params, got %d", len(params), len(actuals))
+		}
+
+		compareValue(&params[0], &actuals[0], t)
+	}, t)
+}
+
+func TestJsonRoundTripProperty(t *testing.T) {
+	runValueProperty(func(value Value, t *testing.T) {
+		var buf bytes.Buffer
+
+		if err := WriteJsonResponse(&buf, &value, nil); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		var response struct {
+			Result json.RawMessage `json:"result"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &response); err != nil {
+			t.Fatalf("Invalid JSON %s: %s", buf.String(), err)
+		}
+
+		request := `{"methodName":"d.multicall","params":[` + string(response.Result) + `]}`
+
+		_, params, err := ParseJsonRequest(strings.NewReader(request))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if len(params) != 1 {
+			t.Fatalf("Expected 1 param, got %d", len(params))
+		}
+
+		compareValue(&value, &params[0], t)
+	}, t)
+}
+
+func FuzzParseResponse(f *testing.F) {
+	for seed := int64(0); seed < 20; seed++ {
+		f.Add(CreateResponse(newValueGenerator(seed).value(0)))
+	}
+	f.Add(CreateFault(FaultInternalError, "Failed"))
+
+	f.Fuzz(func(t *testing.T, document []byte) {
+		value, err := ParseResponse(bytes.NewBuffer(document))
+		if err != nil {
+			return
+		}
+
+		if _, err = ParseResponse(bytes.NewBuffer(CreateResponse(*value))); err != nil {
+			t.Fatalf("Cannot parse encoded %s: %s", printValue(value), err)
+		}
+	})
+}
+
+func FuzzParseJsonRequest(f *testing.F) {
+	for seed := int64(0); seed < 20; seed++ {
+		value := newValueGenerator(seed).value(0)
+
+		var buf bytes.Buffer
+		WriteJsonResponse(&buf, &value, nil)
+
+		f.Add(`{"methodName":"d.multicall","params":[` +
+			strings.TrimSuffix(strings.TrimPrefix(buf.String(), `{"result":`), `}`) + `]}`)
+	}
+
+	f.Fuzz(func(t *testing.T, request string) {
+		ParseJsonRequest(strings.NewReader(request))
+	})
+}
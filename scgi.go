@@ -0,0 +1,107 @@
+package xmlrpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+//Transport for XML-RPC over SCGI, as spoken by rTorrent
+type ScgiTransport struct {
+	Network string //"tcp" or "unix"
+	Address string
+	Dialer  *net.Dialer //zero Dialer if nil
+}
+
+func NewScgiClient(network string, address string) *Client {
+	return &Client{Transport: &ScgiTransport{Network: network, Address: address}}
+}
+
+func (t *ScgiTransport) RoundTrip(ctx context.Context, request io.Reader, length int64) (response io.ReadCloser, err error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+
+	conn, err := dialer.DialContext(ctx, t.Network, t.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+
+	body := &scgiBody{conn: conn, stop: stop}
+
+	if err = writeScgiRequest(conn, request, length); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	body.reader = bufio.NewReader(conn)
+
+	if err = readScgiHeaders(body.reader); err != nil {
+		body.Close()
+		return nil, err
+	}
+
+	return body, nil
+}
+
+//Writes the netstring header block followed by the request body
+func writeScgiRequest(writer io.Writer, request io.Reader, length int64) (err error) {
+	headers := "CONTENT_LENGTH\x00" + strconv.FormatInt(length, 10) + "\x00" +
+		"SCGI\x001\x00"
+
+	bufWriter := bufio.NewWriter(writer)
+
+	bufWriter.WriteString(strconv.Itoa(len(headers)) + ":" + headers + ",")
+
+	n, err := io.Copy(bufWriter, request)
+	if err != nil {
+		return err
+	}
+	if n != length {
+		return fmt.Errorf("Request was %d bytes, expecting %d", n, length)
+	}
+
+	return bufWriter.Flush()
+}
+
+//Reads the CGI style response headers, leaving reader at the start of the body
+func readScgiHeaders(reader *bufio.Reader) (err error) {
+	headers, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		return err
+	}
+
+	if status := headers.Get("Status"); status != "" && !strings.HasPrefix(status, "200") {
+		return fmt.Errorf("Unexpected SCGI status: %s", status)
+	}
+
+	return checkContentType(headers.Get("Content-Type"))
+}
+
+type scgiBody struct {
+	reader *bufio.Reader
+	conn   net.Conn
+	stop   func() bool
+}
+
+func (b *scgiBody) Read(p []byte) (n int, err error) {
+	return b.reader.Read(p)
+}
+
+func (b *scgiBody) Close() (err error) {
+	b.stop()
+	return b.conn.Close()
+}
@@ -0,0 +1,109 @@
+package xmlrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/xml"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func serveScgi(listener net.Listener, response string, t *testing.T) {
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	length, err := reader.ReadString(':')
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	n, _ := strconv.Atoi(strings.TrimSuffix(length, ":"))
+	headers := make([]byte, n+1)
+	if _, err = io.ReadFull(reader, headers); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	fields := strings.Split(string(headers[:n]), "\x00")
+	if len(fields) < 4 || fields[0] != "CONTENT_LENGTH" || fields[2] != "SCGI" || fields[3] != "1" {
+		t.Errorf("Unexpected SCGI headers %q", fields)
+		return
+	}
+
+	expected := string(CreateRequest("system.client_version", nil))
+	if fields[1] != strconv.Itoa(len(expected)) {
+		t.Errorf("Expected CONTENT_LENGTH %d, got %s", len(expected), fields[1])
+	}
+
+	body := make([]byte, len(expected))
+	if _, err = io.ReadFull(reader, body); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+		return
+	}
+
+	if string(body) != expected {
+		t.Errorf("Expected request %s, got %s", expected, body)
+	}
+
+	io.WriteString(conn, response)
+}
+
+func runScgiCall(network string, address string, response string, t *testing.T) (value *Value, err error) {
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer listener.Close()
+
+	done := make(chan struct{})
+	go func() {
+		serveScgi(listener, response, t)
+		close(done)
+	}()
+
+	value, err = NewScgiClient(network, listener.Addr().String()).Call(context.Background(), "system.client_version")
+	<-done
+
+	return value, err
+}
+
+const scgiResponse = "Status: 200 OK\r\nContent-Type: text/xml\r\n\r\n" + xml.Header +
+	"<methodResponse><params><param><value><string>0.9.8</string></value></param></params></methodResponse>"
+
+func TestScgiCallTcp(t *testing.T) {
+	actual, err := runScgiCall("tcp", "127.0.0.1:0", scgiResponse, t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewString("0.9.8")
+	compareValue(&expected, actual, t)
+}
+
+func TestScgiCallUnix(t *testing.T) {
+	actual, err := runScgiCall("unix", filepath.Join(t.TempDir(), "rpc.socket"), scgiResponse, t)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewString("0.9.8")
+	compareValue(&expected, actual, t)
+}
+
+func TestScgiCallBadStatus(t *testing.T) {
+	_, err := runScgiCall("tcp", "127.0.0.1:0", "Status: 500 Internal Server Error\r\n\r\n", t)
+	if err == nil {
+		t.Fatalf("Expected error for bad status")
+	}
+}
@@ -0,0 +1,135 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+//Handles a call to a registered method. Returning a *Fault sends that fault to
+//the caller, any other error is sent as FaultApplicationError. XML-RPC has no
+//void result, so returning Value{} sends FaultInternalError.
+type HandlerFunc func(params []Value) (Value, error)
+
+//Registered method with the metadata used to answer introspection calls
+type Method struct {
+	Handler    HandlerFunc
+	Help       string     //returned by system.methodHelp
+	Signatures [][]string //return type then param types, returned by system.methodSignature
+}
+
+//Serves XML-RPC calls over HTTP, dispatching to registered methods
+type Server struct {
+	DecodeOptions DecodeOptions //decoding requests, such as Strict and limits
+	MaxBytes      int64         //request body size, DefaultMaxBytes when zero
+
+	mutex   sync.RWMutex
+	methods map[string]Method
+}
+
+//Request body size allowed by Server and Gateway when MaxBytes is zero
+const DefaultMaxBytes = 10 << 20
+
+func NewServer() *Server {
+	s := &Server{methods: make(map[string]Method)}
+	s.registerSystem()
+
+	return s
+}
+
+//Registers handler for methodName, replacing any existing method
+func (s *Server) Register(methodName string, handler HandlerFunc) {
+	s.RegisterMethod(methodName, Method{Handler: handler})
+}
+
+//Registers method for methodName, replacing any existing method
+func (s *Server) RegisterMethod(methodName string, method Method) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.methods[methodName] = method
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := checkContentType(r.Header.Get("Content-Type")); err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	decoder := NewDecoder(maxBytesReader(w, r, s.MaxBytes))
+	decoder.DecodeOptions = s.DecodeOptions
+
+	//A fault rather than an HTTP status lets the caller see where parsing failed
+	methodName, params, err := decoder.DecodeRequest()
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			writeResponse(w, CreateFault(FaultParseError, err.Error()))
+		}
+		return
+	}
+
+	value, err := s.call(methodName, params)
+	if err != nil {
+		fault := errorFault(err)
+		writeResponse(w, CreateFault(fault.Code, fault.String))
+		return
+	}
+
+	var buf bytes.Buffer
+
+	if err = NewEncoder(&buf).EncodeResponse(value); err != nil {
+		writeResponse(w, CreateFault(FaultInternalError, "Cannot encode result: "+err.Error()))
+		return
+	}
+
+	writeResponse(w, buf.Bytes())
+}
+
+func (s *Server) call(methodName string, params []Value) (value Value, err error) {
+	s.mutex.RLock()
+	method, ok := s.methods[methodName]
+	s.mutex.RUnlock()
+
+	if !ok {
+		return Value{}, methodNotFound(methodName)
+	}
+
+	value, err = method.Handler(params)
+	if err == nil && value.Kind() == KindInvalid {
+		return Value{}, &Fault{Code: FaultInternalError, String: "Method '" + methodName + "' returned no value"}
+	}
+
+	return value, err
+}
+
+func methodNotFound(methodName string) (fault *Fault) {
+	return &Fault{Code: FaultMethodNotFound, String: "Method '" + methodName + "' not defined"}
+}
+
+//Body of r limited to maxBytes, or DefaultMaxBytes when zero
+func maxBytesReader(w http.ResponseWriter, r *http.Request, maxBytes int64) io.Reader {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	return http.MaxBytesReader(w, r.Body, maxBytes)
+}
+
+func writeResponse(w http.ResponseWriter, document []byte) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(document)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
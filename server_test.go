@@ -0,0 +1,209 @@
+package xmlrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestServer() *httptest.Server {
+	server := NewServer()
+
+	server.Register("echo", func(params []Value) (Value, error) {
+		return NewArray(append([]Value{}, params...)), nil
+	})
+	server.Register("fail", func(params []Value) (Value, error) {
+		return Value{}, errors.New("Failed")
+	})
+	server.Register("fault", func(params []Value) (Value, error) {
+		return Value{}, &Fault{Code: 4, String: "Too many parameters"}
+	})
+
+	return httptest.NewServer(server)
+}
+
+func runServerFault(methodName string, expected Fault, t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	_, err := NewClient(server.URL).Call(context.Background(), methodName)
+
+	var fault *Fault
+	if !errors.As(err, &fault) {
+		t.Fatalf("Expected fault, got %v", err)
+	}
+
+	if fault.Code != expected.Code || (expected.String != "" && fault.String != expected.String) {
+		t.Fatalf("Expected %#v, got %#v", expected, *fault)
+	}
+}
+
+func TestServerCall(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	_, values := structData()
+	params := []Value{NewString("main"), NewInt(-1), values[0]}
+
+	actual, err := NewClient(server.URL).Call(context.Background(), "echo", params...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewArray(params)
+	compareValue(&expected, actual, t)
+}
+
+func TestServerCallNoParams(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	actual, err := NewClient(server.URL).Call(context.Background(), "echo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := NewArray([]Value{})
+	compareValue(&expected, actual, t)
+}
+
+func TestServerMethodNotFound(t *testing.T) {
+	runServerFault("missing", Fault{Code: FaultMethodNotFound}, t)
+}
+
+func TestServerHandlerError(t *testing.T) {
+	runServerFault("fail", Fault{Code: FaultApplicationError, String: "Failed"}, t)
+}
+
+func TestServerHandlerFault(t *testing.T) {
+	runServerFault("fault", Fault{Code: 4, String: "Too many parameters"}, t)
+}
+
+func TestServerHttpStatus(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	requests := []struct {
+		method      string
+		contentType string
+		body        string
+		status      int
+	}{
+		{http.MethodGet, "", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "application/json", "{}", http.StatusUnsupportedMediaType},
+	}
+
+	for _, r := range requests {
+		request, _ := http.NewRequest(r.method, server.URL, strings.NewReader(r.body))
+		if r.contentType != "" {
+			request.Header.Set("Content-Type", r.contentType)
+		}
+
+		response, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		response.Body.Close()
+
+		if response.StatusCode != r.status {
+			t.Fatalf("Expected status %d for %s %s, got %d", r.status, r.method, r.body, response.StatusCode)
+		}
+	}
+}
+
+func TestServerVoidResult(t *testing.T) {
+	server := NewServer()
+	server.Register("void", func(params []Value) (Value, error) {
+		return Value{}, nil
+	})
+	server.Register("nested", func(params []Value) (Value, error) {
+		return NewArray([]Value{{}}), nil
+	})
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	for _, methodName := range []string{"void", "nested"} {
+		_, err := NewClient(httpServer.URL).Call(context.Background(), methodName)
+
+		var fault *Fault
+		if !errors.As(err, &fault) || fault.Code != FaultInternalError {
+			t.Fatalf("Expected internal error fault for %s, got %v", methodName, err)
+		}
+	}
+}
+
+func TestServerDecodeOptions(t *testing.T) {
+	server := NewServer()
+	server.DecodeOptions.Strict = true
+	server.MaxBytes = 1000
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	defaultServer := newTestServer()
+	defer defaultServer.Close()
+
+	nested := strings.Repeat("<value><array><data>", 100000) + strings.Repeat("</data></array></value>", 100000)
+
+	requests := []struct {
+		server *httptest.Server
+		body   string
+		status int
+	}{
+		{httpServer, "<methodCall><methodName>echo</methodName><params><param><value><long>1</long></value></param></params></methodCall>", http.StatusOK},
+		{httpServer, "<methodCall><methodName>" + strings.Repeat("a", 1000) + "</methodName></methodCall>", http.StatusRequestEntityTooLarge},
+		{defaultServer, "<methodCall><methodName>echo</methodName><params><param>" + nested + "</param></params></methodCall>", http.StatusOK},
+	}
+
+	for _, r := range requests {
+		response, err := http.Post(r.server.URL, "text/xml", strings.NewReader(r.body))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		data, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+
+		if response.StatusCode != r.status {
+			t.Fatalf("Expected status %d, got %d %s", r.status, response.StatusCode, data)
+		}
+
+		if r.status == http.StatusOK {
+			_, err = ParseResponse(bytes.NewBuffer(data))
+
+			var fault *Fault
+			if !errors.As(err, &fault) || fault.Code != FaultParseError {
+				t.Fatalf("Expected parse error fault, got %v", err)
+			}
+		}
+	}
+}
+
+func TestServerParseError(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	bodies := []string{"<methodCall><params>", "<methodResponse></methodResponse>"}
+
+	for _, body := range bodies {
+		transport := &httpTransport{url: server.URL, client: http.DefaultClient}
+
+		response, err := transport.RoundTrip(context.Background(), strings.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		_, err = NewDecoder(response).DecodeResponse()
+		response.Close()
+
+		var fault *Fault
+		if !errors.As(err, &fault) || fault.Code != FaultParseError || !strings.Contains(fault.String, "line 1") {
+			t.Fatalf("Expected positioned parse error fault for %s, got %v", body, err)
+		}
+	}
+}
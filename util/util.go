@@ -4,21 +4,35 @@ import (
 	xml "encoding/xml"
 )
 
-func Start(encoder *xml.Encoder, name string) {
-	encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}})
+func Start(encoder *xml.Encoder, name string, attrs ...xml.Attr) error {
+	return encoder.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attrs})
 }
 
-func End(encoder *xml.Encoder, name string) {
-	encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+func End(encoder *xml.Encoder, name string) error {
+	return encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
 }
 
-func Empty(encoder *xml.Encoder, name string) {
-	Start(encoder, name)
-	End(encoder, name)
+func Empty(encoder *xml.Encoder, name string) error {
+	if err := Start(encoder, name); err != nil {
+		return err
+	}
+	return End(encoder, name)
 }
 
-func CharData(encoder *xml.Encoder, str string) {
+func CharData(encoder *xml.Encoder, str string) error {
 	if str != "" {
-		encoder.EncodeToken(xml.CharData(str))
+		return encoder.EncodeToken(xml.CharData(str))
+	}
+	return nil
+}
+
+//Element containing only text
+func Text(encoder *xml.Encoder, name string, str string) error {
+	if err := Start(encoder, name); err != nil {
+		return err
+	}
+	if err := CharData(encoder, str); err != nil {
+		return err
 	}
+	return End(encoder, name)
 }
@@ -1,17 +1,19 @@
 package xmlrpc
 
 import (
-	"encoding/xml"
+	"encoding/base64"
 	"errors"
+	"math"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/literatesnow/xmlrpc/util"
 )
 
 const (
 	iso8601 = "2006-01-02T15:04:05-0700"
+
+	//Namespace of the Apache XML-RPC extension types, such as ex:nil and ex:i8
+	ExtensionsNamespace = "http://ws.apache.org/xmlrpc/namespaces/extensions"
 )
 
 //Represents xmlrpc <member> element (in <struct>)
@@ -56,10 +58,19 @@ func NewDateTime(val time.Time) Value {
 func NewBase64(val string) Value {
 	return Value{Base64: &val}
 }
+func NewBase64Bytes(val []byte) Value {
+	return NewBase64(base64.StdEncoding.EncodeToString(val))
+}
 func NewArray(values []Value) Value {
+	if values == nil {
+		values = []Value{}
+	}
 	return Value{Array: values}
 }
 func NewStruct(members []Member) Value {
+	if members == nil {
+		members = []Member{}
+	}
 	return Value{Struct: members}
 }
 func NewNil() Value {
@@ -79,20 +90,30 @@ func NewShort(val int16) Value {
 	return Value{Short: &val}
 }
 
-func (v *Value) FromString(str string) {
+//Sets the value from its text, returning an error if the text is invalid for
+//the type. The value is still set, to zero or the nearest value in range.
+//A dateTime without a zone is taken to be UTC.
+func (v *Value) FromString(str string) (err error) {
+	return v.fromString(str, time.UTC)
+}
+
+func (v *Value) fromString(str string, loc *time.Location) (err error) {
+	text := strings.TrimSpace(str)
+
 	if v.Int != nil {
-		i, _ := strconv.ParseInt(str, 10, 32)
+		var i int64
+		i, err = strconv.ParseInt(text, 10, 32)
 		*v.Int = int32(i)
 	} else if v.Boolean != nil {
-		*v.Boolean, _ = strconv.ParseBool(str)
+		*v.Boolean, err = strconv.ParseBool(text)
 	} else if v.String != nil {
 		*v.String = str
 	} else if v.Double != nil {
-		*v.Double, _ = strconv.ParseFloat(str, 64)
+		*v.Double, err = strconv.ParseFloat(text, 64)
 	} else if v.DateTime != nil {
-		*v.DateTime, _ = time.Parse(iso8601, str)
+		*v.DateTime, err = parseDateTime(text, loc)
 	} else if v.Base64 != nil {
-		v.Base64 = &str
+		*v.Base64 = stripSpace(str)
 	} else if v.Array != nil {
 		//noop
 	} else if v.Struct != nil {
@@ -100,19 +121,48 @@ func (v *Value) FromString(str string) {
 	} else if v.Nil != nil {
 		//noop
 	} else if v.Byte != nil {
-		i, _ := strconv.Atoi(str)
+		var i int
+		i, err = strconv.Atoi(text)
 		*v.Byte = byte(i)
+		if err == nil && (i < 0 || i > math.MaxUint8) {
+			err = &strconv.NumError{Func: "Atoi", Num: text, Err: strconv.ErrRange}
+		}
 	} else if v.Float != nil {
-		f, _ := strconv.ParseFloat(str, 32)
+		var f float64
+		f, err = strconv.ParseFloat(text, 32)
 		*v.Float = float32(f)
 	} else if v.Long != nil {
-		*v.Long, _ = strconv.ParseInt(str, 10, 64)
+		*v.Long, err = strconv.ParseInt(text, 10, 64)
 	} else if v.Short != nil {
-		i, _ := strconv.ParseInt(str, 10, 16)
+		var i int64
+		i, err = strconv.ParseInt(text, 10, 16)
 		*v.Short = int16(i)
 	} else {
 		v.String = &str
 	}
+
+	return err
+}
+
+//Decodes a base64 value, ignoring white space and missing padding
+func (v *Value) Bytes() (data []byte, err error) {
+	if v.Base64 == nil {
+		return nil, errors.New("Not a base64 value")
+	}
+
+	str := strings.TrimRight(stripSpace(*v.Base64), "=")
+
+	return base64.RawStdEncoding.DecodeString(str)
+}
+
+func stripSpace(str string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, str)
 }
 
 func (v *Value) FromNumber(num float64) {
@@ -247,53 +297,3 @@ func (v *Value) Print() (text string) {
 	dataType, text := v.asString()
 	return "{" + dataType + " " + text + "}"
 }
-
-func (v *Value) asXml(encoder *xml.Encoder) {
-	util.Start(encoder, "value")
-
-	dataType, text := v.asString()
-
-	switch dataType {
-	case "ex:nil":
-		util.Empty(encoder, dataType)
-	case "array":
-		v.xmlArrayValue(encoder, v.Array)
-	case "struct":
-		v.xmlStructValue(encoder, v.Struct)
-	default:
-		v.xmlValue(encoder, dataType, text)
-	}
-
-	util.End(encoder, "value")
-}
-
-func (v *Value) xmlValue(encoder *xml.Encoder, name string, value string) {
-	util.Start(encoder, name)
-	util.CharData(encoder, value)
-	util.End(encoder, name)
-}
-
-func (v *Value) xmlArrayValue(encoder *xml.Encoder, values []Value) {
-	util.Start(encoder, "array")
-	util.Start(encoder, "data")
-
-	for _, val := range values {
-		val.asXml(encoder)
-	}
-
-	util.End(encoder, "data")
-	util.End(encoder, "array")
-}
-
-func (v *Value) xmlStructValue(encoder *xml.Encoder, members []Member) {
-	util.Start(encoder, "struct")
-
-	for _, mem := range members {
-		util.Start(encoder, "name")
-		util.CharData(encoder, mem.Name)
-		util.End(encoder, "name")
-		mem.Value.asXml(encoder)
-	}
-
-	util.End(encoder, "struct")
-}